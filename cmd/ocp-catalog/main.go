@@ -0,0 +1,49 @@
+// Command ocp-catalog exports the flavor, cluster version, networking and addon
+// catalogs to a snapshot file for the provider's catalog_file setting.
+//
+//	OCP_API_TOKEN=... ocp-catalog -region ua -o catalog.json
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/terraform-providers/terraform-provider-onecloud/internal/ocp_client"
+	"os"
+)
+
+func main() {
+	region := flag.String("region", envDefault("OCP_REGION", ocp_client.UARegion), "region to export the catalogs of")
+	output := flag.String("o", "catalog.json", "snapshot file to write")
+	flag.Parse()
+
+	token := os.Getenv("OCP_API_TOKEN")
+	if token == "" {
+		fmt.Fprintln(os.Stderr, "OCP_API_TOKEN is not set")
+		os.Exit(1)
+	}
+
+	client, err := ocp_client.GetClient(token, *region)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	snapshot, err := client.ExportCatalog(context.Background())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	err = snapshot.Write(*output)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Printf("Catalog of region %s written to %s\n", *region, *output)
+}
+
+func envDefault(name, value string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return value
+}
@@ -17,22 +17,26 @@ data "ocp_cluster_version" "list_versions" {
 }
 
 data "ocp_cluster_networking" "list_networking" {
+  version_constraint = "~> 3.26"
+  most_recent        = true
   filter {
-    network_name = "Calico"
-    version      = "v3.26.1"
+    network_name    = "Calico"
+    cluster_version = data.ocp_cluster_version.list_versions.versions[0].version
   }
 }
 
 data "ocp_cluster_addons" "list_addons" {
+  most_recent = true
   filter {
+    cluster_version = data.ocp_cluster_version.list_versions.versions[0].version
   }
 }
 
 output "ms_flavors" {
-  value = data.ocp_flavor.master_flavors.flavors[0].id
+  value = provider::ocp::pick_flavor(data.ocp_flavor.master_flavors.flavors, 2, 4)
 }
 output "nd_flavors" {
-  value = data.ocp_flavor.node_flavors.flavors[0].id
+  value = provider::ocp::pick_flavor(data.ocp_flavor.node_flavors.flavors, 2, 8)
 }
 output "networking" {
   value = data.ocp_cluster_networking.list_networking.networking[0].id
@@ -47,7 +51,7 @@ output "addons" {
 resource "ocp_cluster" "new_cluster" {
   cluster_name     = "cluster-name"
   cluster_version  = data.ocp_cluster_version.list_versions.versions[0].version
-  master_flavor_id = data.ocp_flavor.master_flavors.flavors[0].id
+  master_flavor_id = provider::ocp::pick_flavor(data.ocp_flavor.master_flavors.flavors, 2, 4)
   master_count     = 3
   image            = data.ocp_cluster_version.list_versions.versions[0].images[0].image_name
   networking       = data.ocp_cluster_networking.list_networking.networking[0].id
@@ -55,7 +59,7 @@ resource "ocp_cluster" "new_cluster" {
   restriction_ips  = ["12.12.12.12/32", "13.13.13.13/32"]
   node_pool {
     name       = "nodepool-name"
-    flavor_id  = data.ocp_flavor.node_flavors.flavors[0].id
+    flavor_id  = provider::ocp::pick_flavor(data.ocp_flavor.node_flavors.flavors, 2, 8)
     node_count = 3
     autoscale  = true
     max_count  = 5
@@ -72,7 +76,7 @@ resource "ocp_cluster" "new_cluster" {
 
 resource "ocp_nodepool" "new_nodepool" {
   name = "second-nodepool"
-  flavor_id = data.ocp_flavor.node_flavors.flavors[0].id
+  flavor_id = provider::ocp::pick_flavor(data.ocp_flavor.node_flavors.flavors, 2, 8)
   node_count = 2
   autoscale = false
   cluster = ocp_cluster.new_cluster.id
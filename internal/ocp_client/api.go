@@ -104,10 +104,46 @@ func HandleParams(params interface{}) ([]byte, error) {
 	return jsonBody, nil
 }
 
+// ConflictError is returned when the request is rejected because another
+// operation is in progress on the same object. OperationID is the blocking
+// operation, if the API reports it.
+type ConflictError struct {
+	StatusCode  int
+	OperationID string
+	Body        string
+}
+
+func (e *ConflictError) Error() string {
+	if e.OperationID != "" {
+		return fmt.Sprintf("conflict with operation %s in progress: %s", e.OperationID, e.Body)
+	}
+	return fmt.Sprintf("conflict with an operation in progress: %s", e.Body)
+}
+
+// conflictError detects the responses for a request blocked by an operation in
+// progress: 409, or a 400 that names the blocking operation in operation_id
+// or blocking_operation_id.
+func conflictError(statusCode int, body []byte) *ConflictError {
+	var details map[string]interface{}
+	_ = json.Unmarshal(body, &details)
+
+	operationID, _ := details["operation_id"].(string)
+	if operationID == "" {
+		operationID, _ = details["blocking_operation_id"].(string)
+	}
+	if statusCode == http.StatusConflict || (statusCode == http.StatusBadRequest && operationID != "") {
+		return &ConflictError{StatusCode: statusCode, OperationID: operationID, Body: string(body)}
+	}
+	return nil
+}
+
 func handleStatusCode(statusCode int, body []byte, uri string) error {
 	if statusCode >= http.StatusInternalServerError {
 		return fmt.Errorf("http status %d: service failed.\n%v\n%v", statusCode, body, uri)
 	}
+	if conflict := conflictError(statusCode, body); conflict != nil {
+		return conflict
+	}
 	errBody := errors.New(fmt.Sprintf("Bad requset: %s", body))
 	return errBody
 }
@@ -0,0 +1,150 @@
+package ocp_client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// catalogCache keeps the responses of the read-only catalog endpoints for
+// CatalogTTL. Concurrent requests for the same endpoint share one download.
+type catalogCache struct {
+	lock    sync.Mutex
+	entries map[string]*catalogEntry
+}
+
+type catalogEntry struct {
+	done    chan struct{}
+	body    []byte
+	err     error
+	fetched time.Time
+}
+
+func newCatalogCache() *catalogCache {
+	return &catalogCache{entries: make(map[string]*catalogEntry)}
+}
+
+// getCatalog returns the response body of a catalog endpoint, from the cache
+// if it is younger than CatalogTTL. Errors are not cached.
+func (c *Client) getCatalog(ctx context.Context, uri string) ([]byte, error) {
+	if c.CatalogTTL <= 0 || c.catalog == nil {
+		resp, _, err := c.API.makeRequest(ctx, http.MethodGet, uri, nil)
+		return resp, err
+	}
+
+	cache := c.catalog
+	cache.lock.Lock()
+	entry := cache.entries[uri]
+	if entry != nil && !entry.expired(c.CatalogTTL) {
+		cache.lock.Unlock()
+		select {
+		case <-entry.done:
+			return entry.body, entry.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	entry = &catalogEntry{done: make(chan struct{})}
+	cache.entries[uri] = entry
+	cache.lock.Unlock()
+
+	entry.body, _, entry.err = c.API.makeRequest(ctx, http.MethodGet, uri, nil)
+	entry.fetched = time.Now()
+	close(entry.done)
+	if entry.err != nil {
+		cache.lock.Lock()
+		if cache.entries[uri] == entry {
+			delete(cache.entries, uri)
+		}
+		cache.lock.Unlock()
+	}
+	return entry.body, entry.err
+}
+
+func (e *catalogEntry) expired(ttl time.Duration) bool {
+	select {
+	case <-e.done:
+		return time.Since(e.fetched) > ttl
+	default:
+		return false
+	}
+}
+
+// CatalogSnapshotVersion is the format version written by ExportCatalog.
+const CatalogSnapshotVersion = 1
+
+// CatalogSnapshot is an offline copy of the catalogs. When Client.Snapshot is
+// set, the catalog methods read from it instead of the API.
+type CatalogSnapshot struct {
+	FormatVersion   int              `json:"format_version"`
+	Region          string           `json:"region"`
+	CreatedAt       time.Time        `json:"created_at"`
+	Flavors         []Flavor         `json:"flavors"`
+	ClusterVersions []ClusterVersion `json:"cluster_versions"`
+	Networking      []Networking     `json:"networking"`
+	ClusterAddons   []ClusterAddon   `json:"cluster_addons"`
+}
+
+// ExportCatalog downloads all catalogs from the API.
+func (c *Client) ExportCatalog(ctx context.Context) (*CatalogSnapshot, error) {
+	live := *c
+	live.Snapshot = nil
+
+	snapshot := &CatalogSnapshot{
+		FormatVersion: CatalogSnapshotVersion,
+		Region:        c.Region,
+		CreatedAt:     time.Now().UTC().Truncate(time.Second),
+	}
+	var err error
+	if snapshot.Flavors, err = live.Flavors(ctx); err != nil {
+		return nil, err
+	}
+	if snapshot.ClusterVersions, err = live.ClusterVersions(ctx); err != nil {
+		return nil, err
+	}
+	if snapshot.Networking, err = live.Networking(ctx); err != nil {
+		return nil, err
+	}
+	if snapshot.ClusterAddons, err = live.ClusterAddons(ctx); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+func LoadCatalogSnapshot(path string) (*CatalogSnapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var snapshot CatalogSnapshot
+	err = json.Unmarshal(data, &snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("error when decoding catalog snapshot %s, %w", path, err)
+	}
+	if snapshot.FormatVersion != CatalogSnapshotVersion {
+		return nil, fmt.Errorf("catalog snapshot %s has format version %d, version %d is supported",
+			path, snapshot.FormatVersion, CatalogSnapshotVersion)
+	}
+	return &snapshot, nil
+}
+
+func (s *CatalogSnapshot) Write(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Equal reports whether both snapshots hold the same catalogs, ignoring when
+// they were created.
+func (s *CatalogSnapshot) Equal(other *CatalogSnapshot) bool {
+	a, b := *s, *other
+	a.CreatedAt, b.CreatedAt = time.Time{}, time.Time{}
+	return reflect.DeepEqual(a, b)
+}
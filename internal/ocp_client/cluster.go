@@ -68,3 +68,35 @@ func (c *Client) DeleteCluster(ctx context.Context, clusterId string) (map[strin
 	}
 	return result, nil
 }
+
+// ReplaceControlNode replaces one control plane node, data selects the flavor
+// of the new node. The response holds the operation_id of the replacement.
+func (c *Client) ReplaceControlNode(ctx context.Context, clusterId, nodeId string, data interface{}) (map[string]interface{}, error) {
+	resp, _, err := c.API.makeRequest(ctx, http.MethodPost, ClusterUri+clusterId+"/control-nodes/"+nodeId+"/replace/", data)
+	if err != nil {
+		return nil, err
+	}
+
+	var result map[string]interface{}
+	err = json.Unmarshal(resp, &result)
+	if err != nil {
+		return result, fmt.Errorf("error when decoding json response, %w", err)
+	}
+	return result, nil
+}
+
+// GetClusterHealth returns the health of the etcd members and the control
+// plane components of a cluster.
+func (c *Client) GetClusterHealth(ctx context.Context, clusterId string) (map[string]interface{}, error) {
+	resp, _, err := c.API.makeRequest(ctx, http.MethodGet, ClusterUri+clusterId+"/health/", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result map[string]interface{}
+	err = json.Unmarshal(resp, &result)
+	if err != nil {
+		return result, fmt.Errorf("error when decoding json response, %w", err)
+	}
+	return result, nil
+}
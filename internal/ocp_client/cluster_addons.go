@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"net/http"
 )
 
 const ClusterAddonsUri = "cluster/addons"
@@ -17,12 +16,16 @@ type ClusterAddon struct {
 }
 
 type Release struct {
-	ID      string `json:"id"`
-	Version string `json:"version"`
+	ID              string   `json:"id"`
+	Version         string   `json:"version"`
+	ClusterVersions []string `json:"cluster_versions"`
 }
 
 func (c *Client) ClusterAddons(ctx context.Context) ([]ClusterAddon, error) {
-	resp, _, err := c.API.makeRequest(ctx, http.MethodGet, ClusterAddonsUri, nil)
+	if c.Snapshot != nil {
+		return append([]ClusterAddon(nil), c.Snapshot.ClusterAddons...), nil
+	}
+	resp, err := c.getCatalog(ctx, ClusterAddonsUri)
 	if err != nil {
 		return nil, err
 	}
@@ -4,15 +4,19 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"net/http"
 )
 
 const ClusterVersionsUri = "cluster/versions"
 
 type ClusterVersion struct {
-	ID      string  `json:"id"`
-	Version string  `json:"version"`
-	Images  []Image `json:"images"`
+	ID                string   `json:"id"`
+	Version           string   `json:"version"`
+	Default           bool     `json:"default"`
+	Deprecated        bool     `json:"deprecated"`
+	AvailableUpgrades []string `json:"available_upgrades"`
+	ReleaseDate       string   `json:"release_date"`
+	EndOfSupport      string   `json:"end_of_support"`
+	Images            []Image  `json:"images"`
 }
 
 type Image struct {
@@ -20,10 +24,28 @@ type Image struct {
 	ImageName   string `json:"image_name"`
 	OpenstackId string `json:"openstack_id"`
 	OsDistro    string `json:"os_distro"`
+	Default     bool   `json:"default"`
+}
+
+// DefaultImage returns the image marked as default, or the first image if the
+// API doesn't mark one.
+func (v ClusterVersion) DefaultImage() *Image {
+	for i := range v.Images {
+		if v.Images[i].Default {
+			return &v.Images[i]
+		}
+	}
+	if len(v.Images) > 0 {
+		return &v.Images[0]
+	}
+	return nil
 }
 
 func (c *Client) ClusterVersions(ctx context.Context) ([]ClusterVersion, error) {
-	resp, _, err := c.API.makeRequest(ctx, http.MethodGet, ClusterVersionsUri, nil)
+	if c.Snapshot != nil {
+		return append([]ClusterVersion(nil), c.Snapshot.ClusterVersions...), nil
+	}
+	resp, err := c.getCatalog(ctx, ClusterVersionsUri)
 	if err != nil {
 		return nil, err
 	}
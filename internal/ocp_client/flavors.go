@@ -4,7 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"net/http"
+	"strings"
 )
 
 const FlavorsUri = "openstack/instances/create_options"
@@ -29,7 +29,10 @@ type Flavor struct {
 }
 
 func (c *Client) Flavors(ctx context.Context) ([]Flavor, error) {
-	resp, _, err := c.API.makeRequest(ctx, http.MethodGet, FlavorsUri, nil)
+	if c.Snapshot != nil {
+		return append([]Flavor(nil), c.Snapshot.Flavors...), nil
+	}
+	resp, err := c.getCatalog(ctx, FlavorsUri)
 	if err != nil {
 		return nil, err
 	}
@@ -44,3 +47,31 @@ func (c *Client) Flavors(ctx context.Context) ([]Flavor, error) {
 
 	return result.Flavor, nil
 }
+
+// ExtraSpecs parses Properties into a map. The API returns either a JSON
+// object or the OpenStack CLI format: key='value', key2='value2'.
+func (f Flavor) ExtraSpecs() map[string]string {
+	specs := make(map[string]string)
+	properties := strings.TrimSpace(f.Properties)
+	if properties == "" {
+		return specs
+	}
+
+	var object map[string]interface{}
+	if json.Unmarshal([]byte(properties), &object) == nil {
+		for key, value := range object {
+			specs[key] = fmt.Sprint(value)
+		}
+		return specs
+	}
+
+	for _, pair := range strings.Split(properties, ",") {
+		key, value, found := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			continue
+		}
+		specs[key] = strings.Trim(strings.TrimSpace(value), `'"`)
+	}
+	return specs
+}
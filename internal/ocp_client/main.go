@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 )
 
 const (
@@ -19,6 +20,12 @@ var regions = map[string]string{
 type Client struct {
 	Region string
 	API    *API
+	// CatalogTTL is how long flavor, version, addon and networking catalogs
+	// are reused, 0 disables the cache.
+	CatalogTTL time.Duration
+	// Snapshot, if set, serves the catalogs instead of the API.
+	Snapshot *CatalogSnapshot
+	catalog  *catalogCache
 }
 
 func GetClient(token, region string) (*Client, error) {
@@ -34,6 +41,7 @@ func GetClient(token, region string) (*Client, error) {
 			Endpoint:   endpoint,
 			UserAgent:  userAgent,
 		},
+		catalog: newCatalogCache(),
 	}
 	return client, nil
 }
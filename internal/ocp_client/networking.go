@@ -4,19 +4,22 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"net/http"
 )
 
 const NetworkingUri = "cluster/networking"
 
 type Networking struct {
-	ID      string `json:"id"`
-	Name    string `json:"network_name"`
-	Version string `json:"version"`
+	ID              string   `json:"id"`
+	Name            string   `json:"network_name"`
+	Version         string   `json:"version"`
+	ClusterVersions []string `json:"cluster_versions"`
 }
 
 func (c *Client) Networking(ctx context.Context) ([]Networking, error) {
-	resp, _, err := c.API.makeRequest(ctx, http.MethodGet, NetworkingUri, nil)
+	if c.Snapshot != nil {
+		return append([]Networking(nil), c.Snapshot.Networking...), nil
+	}
+	resp, err := c.getCatalog(ctx, NetworkingUri)
 	if err != nil {
 		return nil, err
 	}
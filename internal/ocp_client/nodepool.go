@@ -51,9 +51,28 @@ func (c *Client) UpdateNodePool(ctx context.Context, NodePoolId string, data int
 	return result, nil
 }
 
+func (c *Client) DrainNodePool(ctx context.Context, NodePoolId string, data interface{}) (map[string]interface{}, error) {
+	resp, _, err := c.API.makeRequest(ctx, http.MethodPost, NodePoolUri+NodePoolId+"/drain/", data)
+	if err != nil {
+		return nil, err
+	}
+
+	var result map[string]interface{}
+	err = json.Unmarshal(resp, &result)
+	if err != nil {
+		return result, fmt.Errorf("error when decoding json response, %w", err)
+	}
+	return result, nil
+}
+
+// DeleteNodePool deletes a node pool, a node pool that doesn't exist is not
+// an error.
 func (c *Client) DeleteNodePool(ctx context.Context, NodePoolId string) error {
-	_, _, err := c.API.makeRequest(ctx, http.MethodDelete, NodePoolUri+NodePoolId+"/", nil)
+	_, code, err := c.API.makeRequest(ctx, http.MethodDelete, NodePoolUri+NodePoolId+"/", nil)
 	if err != nil {
+		if code == 404 {
+			return nil
+		}
 		return err
 	}
 
@@ -5,10 +5,125 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"time"
 )
 
 const OperationsUri = "operations/"
 
+type Operation struct {
+	ID              string            `json:"id"`
+	OperationType   string            `json:"operation_type"`
+	Status          string            `json:"status"`
+	PrimaryObjectID string            `json:"primary_object_id"`
+	Initiator       string            `json:"initiator"`
+	StartedAt       string            `json:"started_at"`
+	FinishedAt      string            `json:"finished_at"`
+	ErrorMessage    string            `json:"error_message"`
+	Progress        OperationProgress `json:"progress"`
+	// Started is StartedAt parsed by ListOperations, zero if it can't be
+	// parsed.
+	Started time.Time `json:"-"`
+}
+
+// operationTimeLayouts are the timestamp formats the API uses, RFC 3339 with
+// or without fractional seconds, and without a zone for UTC.
+var operationTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+}
+
+// parseOperationTime parses a timestamp of an operation, timestamps without a
+// zone are UTC.
+func parseOperationTime(value string) (time.Time, error) {
+	var err error
+	for _, layout := range operationTimeLayouts {
+		var t time.Time
+		t, err = time.Parse(layout, value)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// operationsPage is a page of ListOperations, next is the URL of the next
+// page, empty on the last one.
+type operationsPage struct {
+	Next    string      `json:"next"`
+	Results []Operation `json:"results"`
+}
+
+type OperationProgress struct {
+	CompletedSteps int             `json:"completed_steps"`
+	StepsDetails   []OperationStep `json:"steps_details"`
+}
+
+type OperationStep struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Error      string `json:"error"`
+	StartedAt  string `json:"started_at"`
+	FinishedAt string `json:"finished_at"`
+}
+
+// OperationFilter selects operations in ListOperations, empty fields match
+// any operation.
+type OperationFilter struct {
+	ObjectID      string
+	OperationType string
+	Status        string
+	StartedAfter  time.Time
+	StartedBefore time.Time
+}
+
+func (f OperationFilter) query() url.Values {
+	query := url.Values{}
+	if f.ObjectID != "" {
+		query.Set("primary_object_id", f.ObjectID)
+	}
+	if f.OperationType != "" {
+		query.Set("operation_type", f.OperationType)
+	}
+	if f.Status != "" {
+		query.Set("status", f.Status)
+	}
+	if !f.StartedAfter.IsZero() {
+		query.Set("started_after", f.StartedAfter.Format(time.RFC3339))
+	}
+	if !f.StartedBefore.IsZero() {
+		query.Set("started_before", f.StartedBefore.Format(time.RFC3339))
+	}
+	return query
+}
+
+// matches checks the operation against the filter, in case the API ignores
+// some of the query parameters. An operation with a start time that can't be
+// parsed is left to the API's time filter.
+func (f OperationFilter) matches(op Operation) bool {
+	if f.ObjectID != "" && op.PrimaryObjectID != f.ObjectID {
+		return false
+	}
+	if f.OperationType != "" && op.OperationType != f.OperationType {
+		return false
+	}
+	if f.Status != "" && op.Status != f.Status {
+		return false
+	}
+	if op.Started.IsZero() {
+		return true
+	}
+	if !f.StartedAfter.IsZero() && op.Started.Before(f.StartedAfter) {
+		return false
+	}
+	if !f.StartedBefore.IsZero() && !op.Started.Before(f.StartedBefore) {
+		return false
+	}
+	return true
+}
+
 func (c *Client) GetOperation(ctx context.Context, id string) (map[string]interface{}, int, error) {
 	resp, code, err := c.API.makeRequest(ctx, http.MethodGet, OperationsUri+id, nil)
 	if err != nil {
@@ -22,3 +137,63 @@ func (c *Client) GetOperation(ctx context.Context, id string) (map[string]interf
 	}
 	return result, code, nil
 }
+
+func (c *Client) CancelOperation(ctx context.Context, id string) (map[string]interface{}, error) {
+	resp, _, err := c.API.makeRequest(ctx, http.MethodPost, OperationsUri+id+"/cancel/", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result map[string]interface{}
+	err = json.Unmarshal(resp, &result)
+	if err != nil {
+		return result, fmt.Errorf("error when decoding json response, %w", err)
+	}
+	return result, nil
+}
+
+// ListOperations returns the operations matching the filter from all pages.
+// The API returns either a bare array or pages with the results and the URL
+// of the next page.
+func (c *Client) ListOperations(ctx context.Context, filter OperationFilter) ([]Operation, error) {
+	query := filter.query()
+	var operations []Operation
+	for {
+		uri := OperationsUri
+		if len(query) > 0 {
+			uri += "?" + query.Encode()
+		}
+		resp, _, err := c.API.makeRequest(ctx, http.MethodGet, uri, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var page operationsPage
+		if err := json.Unmarshal(resp, &page.Results); err != nil {
+			err = json.Unmarshal(resp, &page)
+			if err != nil {
+				return []Operation{}, fmt.Errorf("Error during Unmarshal, %w", err)
+			}
+		}
+
+		for _, op := range page.Results {
+			op.Started, _ = parseOperationTime(op.StartedAt)
+			if filter.matches(op) {
+				operations = append(operations, op)
+			}
+		}
+
+		if page.Next == "" {
+			break
+		}
+		next, err := url.Parse(page.Next)
+		if err != nil {
+			return nil, fmt.Errorf("invalid next page %q, %w", page.Next, err)
+		}
+		query = next.Query()
+	}
+	if operations == nil {
+		operations = []Operation{}
+	}
+	return operations, nil
+}
@@ -20,15 +20,29 @@ type ClusterCreateOptions struct {
 }
 
 type NodePoolCreateOptions struct {
-	Name      string  `json:"name"`
-	FlavorId  string  `json:"flavor_id"`
-	Count     int     `json:"count"`
-	Autoscale bool    `json:"autoscale"`
-	MaxCount  int     `json:"max_count"`
-	IsDefault bool    `json:"is_default"`
-	Labels    []Label `json:"labels"`
-	Taints    []Taint `json:"taints"`
-	Cluster   string  `json:"cluster"`
+	Name              string           `json:"name"`
+	FlavorId          string           `json:"flavor_id"`
+	Image             string           `json:"image,omitempty"`
+	KubernetesVersion string           `json:"kubernetes_version,omitempty"`
+	Count             int              `json:"count"`
+	Autoscale         bool             `json:"autoscale"`
+	MaxCount          int              `json:"max_count"`
+	IsDefault         bool             `json:"is_default"`
+	Labels            []Label          `json:"labels"`
+	Taints            []Taint          `json:"taints"`
+	Cluster           string           `json:"cluster"`
+	UpgradeSettings   *UpgradeSettings `json:"upgrade_settings"`
+}
+
+// UpgradeSettings controls how nodes of a node pool are replaced on scale-down,
+// flavor, image and version changes. Every node is cordoned and drained before
+// it is deleted, DrainTimeout is in seconds.
+type UpgradeSettings struct {
+	MaxSurge               int  `json:"max_surge"`
+	MaxUnavailable         int  `json:"max_unavailable"`
+	Drain                  bool `json:"drain"`
+	DrainTimeout           int  `json:"drain_timeout"`
+	ForceDrainAfterTimeout bool `json:"force_drain_after_timeout"`
 }
 
 type Label struct {
@@ -73,15 +87,18 @@ func GetClusterCreateOptions(d *schema.ResourceData) *ClusterCreateOptions {
 
 func GetNodePoolCreateOptions(d *schema.ResourceData) *NodePoolCreateOptions {
 	return &NodePoolCreateOptions{
-		Name:      d.Get("name").(string),
-		FlavorId:  d.Get("flavor_id").(string),
-		Count:     d.Get("node_count").(int),
-		Autoscale: d.Get("autoscale").(bool),
-		MaxCount:  d.Get("max_count").(int),
-		IsDefault: false,
-		Labels:    getLabels(d.Get("labels").([]interface{})),
-		Taints:    getTaints(d.Get("taints").([]interface{})),
-		Cluster:   d.Get("cluster").(string),
+		Name:              d.Get("name").(string),
+		FlavorId:          d.Get("flavor_id").(string),
+		Image:             d.Get("image").(string),
+		KubernetesVersion: d.Get("kubernetes_version").(string),
+		Count:             d.Get("node_count").(int),
+		Autoscale:         d.Get("autoscale").(bool),
+		MaxCount:          d.Get("max_count").(int),
+		IsDefault:         false,
+		Labels:            getLabels(d.Get("labels").([]interface{})),
+		Taints:            getTaints(d.Get("taints").([]interface{})),
+		Cluster:           d.Get("cluster").(string),
+		UpgradeSettings:   getUpgradeSettings(d.Get("upgrade_settings").([]interface{})),
 	}
 }
 
@@ -97,6 +114,34 @@ func getAddons(data []interface{}) []Addon {
 	return addons
 }
 
+func getUpgradeSettings(data []interface{}) *UpgradeSettings {
+	settings := &UpgradeSettings{
+		MaxSurge:               1,
+		MaxUnavailable:         0,
+		Drain:                  true,
+		DrainTimeout:           defaultDrainTimeout,
+		ForceDrainAfterTimeout: false,
+	}
+	if len(data) == 0 || data[0] == nil {
+		return settings
+	}
+	settingsMap := data[0].(map[string]interface{})
+	settings.MaxSurge = settingsMap["max_surge"].(int)
+	settings.MaxUnavailable = settingsMap["max_unavailable"].(int)
+	settings.DrainTimeout = settingsMap["drain_timeout"].(int)
+	settings.ForceDrainAfterTimeout = settingsMap["force_drain_after_timeout"].(bool)
+	return settings
+}
+
+func flattenUpgradeSettings(settings map[string]interface{}) []map[string]interface{} {
+	return []map[string]interface{}{{
+		"max_surge":                 settings["max_surge"],
+		"max_unavailable":           settings["max_unavailable"],
+		"drain_timeout":             settings["drain_timeout"],
+		"force_drain_after_timeout": settings["force_drain_after_timeout"],
+	}}
+}
+
 func getLabels(data []interface{}) []Label {
 	labels := make([]Label, len(data))
 	for i, item := range data {
@@ -125,14 +170,17 @@ func getTaints(data []interface{}) []Taint {
 func getNodePool(data []interface{}) []NodePoolCreateOptions {
 	NodepoolMap := data[0].(map[string]interface{})
 	return []NodePoolCreateOptions{{
-		Name:      NodepoolMap["name"].(string),
-		FlavorId:  NodepoolMap["flavor_id"].(string),
-		Count:     NodepoolMap["node_count"].(int),
-		Autoscale: NodepoolMap["autoscale"].(bool),
-		MaxCount:  NodepoolMap["max_count"].(int),
-		IsDefault: true,
-		Labels:    make([]Label, 0),
-		Taints:    make([]Taint, 0),
+		Name:              NodepoolMap["name"].(string),
+		FlavorId:          NodepoolMap["flavor_id"].(string),
+		Image:             NodepoolMap["image"].(string),
+		KubernetesVersion: NodepoolMap["kubernetes_version"].(string),
+		Count:             NodepoolMap["node_count"].(int),
+		Autoscale:         NodepoolMap["autoscale"].(bool),
+		MaxCount:          NodepoolMap["max_count"].(int),
+		IsDefault:         true,
+		Labels:            make([]Label, 0),
+		Taints:            make([]Taint, 0),
+		UpgradeSettings:   getUpgradeSettings(NodepoolMap["upgrade_settings"].([]interface{})),
 	}}
 }
 
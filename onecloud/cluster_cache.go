@@ -0,0 +1,108 @@
+package onecloud
+
+import (
+	"context"
+	"github.com/terraform-providers/terraform-provider-onecloud/internal/ocp_client"
+	"sync"
+	"time"
+)
+
+// clusterCache shares GetCluster responses between the cluster and node pool
+// reads of a refresh. Concurrent reads of the same cluster make one request.
+// A payload is reused for ttl, set by cluster_cache_ttl, mutations of the
+// cluster or its node pools drop it earlier. Payloads are shared and must not
+// be modified.
+type clusterCache struct {
+	lock    sync.Mutex
+	ttl     time.Duration
+	entries map[string]*clusterCacheEntry
+}
+
+type clusterCacheEntry struct {
+	done    chan struct{}
+	cluster map[string]interface{}
+	err     error
+	fetched time.Time
+}
+
+func newClusterCache(ttl time.Duration) *clusterCache {
+	return &clusterCache{ttl: ttl, entries: make(map[string]*clusterCacheEntry)}
+}
+
+// get returns the cluster, nil if it doesn't exist.
+func (c *clusterCache) get(ctx context.Context, client *ocp_client.Client, clusterId string) (map[string]interface{}, error) {
+	c.lock.Lock()
+	entry := c.entries[clusterId]
+	if entry != nil && !entry.expired(c.ttl) {
+		c.lock.Unlock()
+		select {
+		case <-entry.done:
+			return entry.cluster, entry.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	entry = &clusterCacheEntry{done: make(chan struct{})}
+	c.entries[clusterId] = entry
+	c.lock.Unlock()
+
+	entry.cluster, entry.err = client.GetCluster(ctx, clusterId)
+	entry.fetched = time.Now()
+	close(entry.done)
+	if entry.err != nil {
+		c.drop(clusterId, entry)
+	}
+	return entry.cluster, entry.err
+}
+
+// invalidate drops the cached cluster, the next read fetches it again.
+func (c *clusterCache) invalidate(clusterId string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.entries, clusterId)
+}
+
+func (c *clusterCache) drop(clusterId string, entry *clusterCacheEntry) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.entries[clusterId] == entry {
+		delete(c.entries, clusterId)
+	}
+}
+
+// expired reports whether a finished fetch is too old. A fetch in progress is
+// never expired, callers wait for it.
+func (e *clusterCacheEntry) expired(ttl time.Duration) bool {
+	select {
+	case <-e.done:
+		return time.Since(e.fetched) >= ttl
+	default:
+		return false
+	}
+}
+
+// nodePoolRequiredFields are the node pool fields an embedded node pool must
+// have to be used by Read. The other fields read by fetchNodePoolState keep
+// their state when the payload leaves them out.
+var nodePoolRequiredFields = []string{"name", "flavor", "count", "status", "nodes"}
+
+// clusterNodePool returns the node pool from the cluster payload, or nil if
+// the payload doesn't include it or lacks one of nodePoolRequiredFields, so the
+// caller reads the node pool itself.
+func clusterNodePool(cluster map[string]interface{}, nodePoolId string) map[string]interface{} {
+	nodePools, _ := cluster["node_pools"].([]interface{})
+	for _, np := range nodePools {
+		nodePool, ok := np.(map[string]interface{})
+		if !ok || nodePool["id"] != nodePoolId {
+			continue
+		}
+		for _, field := range nodePoolRequiredFields {
+			if _, ok := nodePool[field]; !ok {
+				return nil
+			}
+		}
+		return nodePool
+	}
+	return nil
+}
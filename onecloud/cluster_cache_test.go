@@ -0,0 +1,151 @@
+package onecloud
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/terraform-providers/terraform-provider-onecloud/internal/ocp_client"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func benchmarkNodePool(id string, omit []string) map[string]interface{} {
+	nodePool := map[string]interface{}{
+		"id":         id,
+		"name":       id,
+		"flavor":     "flavor",
+		"image":      "image",
+		"count":      3,
+		"autoscale":  false,
+		"max_count":  nil,
+		"is_default": id == "np-0",
+		"status":     "ready",
+		"upgrade_settings": map[string]interface{}{
+			"max_surge":                 1,
+			"max_unavailable":           0,
+			"drain_timeout":             600,
+			"force_drain_after_timeout": false,
+		},
+		"labels": []interface{}{map[string]interface{}{"key": "role", "value": "worker"}},
+		"taints": []interface{}{},
+		"nodes":  []interface{}{},
+	}
+	for _, field := range omit {
+		delete(nodePool, field)
+	}
+	return nodePool
+}
+
+// newBenchmarkServer serves a cluster with nodePools node pools besides the
+// default one and counts the requests. The embedded node pools leave out the
+// omit fields.
+func newBenchmarkServer(nodePools int, omit []string) (*httptest.Server, *int64) {
+	var calls int64
+	pools := []interface{}{benchmarkNodePool("np-0", omit)}
+	for i := 1; i <= nodePools; i++ {
+		pools = append(pools, benchmarkNodePool(fmt.Sprintf("np-%d", i), omit))
+	}
+	cluster := map[string]interface{}{
+		"id":              "cluster",
+		"cluster_name":    "cluster",
+		"cluster_version": "1.29.0",
+		"master_count":    3,
+		"restriction_api": false,
+		"restriction_ips": []interface{}{},
+		"control_nodes":   []interface{}{},
+		"status":          "ready",
+		"node_pools":      pools,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&calls, 1)
+		path := strings.TrimPrefix(r.URL.Path, "/")
+		var body interface{}
+		switch {
+		case path == ocp_client.ClusterVersionsUri:
+			body = []interface{}{}
+		case path == ocp_client.ClusterUri+"cluster/":
+			body = cluster
+		case strings.HasPrefix(path, ocp_client.NodePoolUri):
+			id := strings.TrimPrefix(path, ocp_client.NodePoolUri)
+			body = benchmarkNodePool(id, nil)
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(body) //nolint
+	}))
+	return server, &calls
+}
+
+func benchmarkRefresh(b *testing.B, nodePools int, omit []string) {
+	server, calls := newBenchmarkServer(nodePools, omit)
+	defer server.Close()
+
+	client, err := ocp_client.GetClient("token", ocp_client.UARegion)
+	if err != nil {
+		b.Fatal(err)
+	}
+	client.API.Endpoint = server.URL + "/"
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// Every refresh starts with an empty cluster cache.
+		config := &Config{
+			client:       client,
+			clusters:     newClusterCache(time.Minute),
+			clusterLocks: make(map[string]chan struct{}),
+		}
+
+		cluster := resourceCluster().TestResourceData()
+		cluster.SetId("cluster")
+		err := cluster.Set("node_pool", []interface{}{map[string]interface{}{"name": "np-0"}})
+		if err != nil {
+			b.Fatal(err)
+		}
+		if diags := resourceOCPClusterRead(ctx, cluster, config); diags.HasError() {
+			b.Fatal(diags)
+		}
+
+		for j := 1; j <= nodePools; j++ {
+			nodePool := resourceNodePool().TestResourceData()
+			nodePool.SetId(fmt.Sprintf("np-%d", j))
+			err := nodePool.Set("cluster", "cluster")
+			if err != nil {
+				b.Fatal(err)
+			}
+			// Labels from the previous refresh, kept if the payload leaves them
+			// out.
+			err = nodePool.Set("labels", []interface{}{map[string]interface{}{"key": "role", "value": "worker"}})
+			if err != nil {
+				b.Fatal(err)
+			}
+			if diags := resourceOCPNodePoolRead(ctx, nodePool, config); diags.HasError() {
+				b.Fatal(diags)
+			}
+			if len(nodePool.Get("labels").([]interface{})) != 1 {
+				b.Fatalf("node pool %s lost its labels", nodePool.Id())
+			}
+		}
+	}
+	b.ReportMetric(float64(atomic.LoadInt64(calls))/float64(b.N), "calls/op")
+}
+
+func BenchmarkRefreshClusterNodePools(b *testing.B) {
+	for _, nodePools := range []int{1, 10, 50} {
+		b.Run(fmt.Sprintf("embedded/%d", nodePools), func(b *testing.B) {
+			benchmarkRefresh(b, nodePools, nil)
+		})
+		b.Run(fmt.Sprintf("partial/%d", nodePools), func(b *testing.B) {
+			benchmarkRefresh(b, nodePools, []string{"labels", "taints", "upgrade_settings"})
+		})
+		b.Run(fmt.Sprintf("fallback/%d", nodePools), func(b *testing.B) {
+			benchmarkRefresh(b, nodePools, []string{"count"})
+		})
+	}
+}
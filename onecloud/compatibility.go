@@ -0,0 +1,192 @@
+package onecloud
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/terraform-providers/terraform-provider-onecloud/internal/ocp_client"
+)
+
+// compatibilityCatalog holds the catalogs a cluster combines: versions with
+// their images, networking and addon releases. Networking and releases list
+// the cluster versions they support.
+type compatibilityCatalog struct {
+	versions   []ocp_client.ClusterVersion
+	networking []ocp_client.Networking
+	addons     []ocp_client.ClusterAddon
+}
+
+// clusterCompatibility is what can be combined with one cluster version.
+type clusterCompatibility struct {
+	version    ocp_client.ClusterVersion
+	networking []ocp_client.Networking
+	addons     []ocp_client.ClusterAddon
+}
+
+func getCompatibilityCatalog(ctx context.Context, client *ocp_client.Client) (*compatibilityCatalog, error) {
+	versions, err := client.ClusterVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	networking, err := client.Networking(ctx)
+	if err != nil {
+		return nil, err
+	}
+	addons, err := client.ClusterAddons(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sortClusterVersions(versions)
+	sortNetworking(networking)
+	return &compatibilityCatalog{versions: versions, networking: networking, addons: addons}, nil
+}
+
+// forVersion returns the networking and addon releases supporting a cluster
+// version, newest first.
+func (c *compatibilityCatalog) forVersion(clusterVersion ocp_client.ClusterVersion) clusterCompatibility {
+	networking := filterNetworking(c.networking, networkingSearchFilter{clusterVersion: clusterVersion.Version})
+	addons := filterClusterAddons(c.addons, addonSearchFilter{clusterVersion: clusterVersion.Version})
+	return clusterCompatibility{version: clusterVersion, networking: networking, addons: addons}
+}
+
+// checkVersion returns the compatibility of a cluster version, or an error if
+// the catalog doesn't offer it.
+func (c *compatibilityCatalog) checkVersion(clusterVersion string) (clusterCompatibility, error) {
+	catalogVersion := findClusterVersion(c.versions, clusterVersion)
+	if catalogVersion == nil {
+		var available []string
+		for _, v := range c.versions {
+			if !v.Deprecated {
+				available = append(available, v.Version)
+			}
+		}
+		return clusterCompatibility{}, fmt.Errorf(
+			"cluster version %s is not available, available versions: %v", clusterVersion, available)
+	}
+	return c.forVersion(*catalogVersion), nil
+}
+
+// checkImage accepts the image name, system name or Openstack ID of an image
+// of the version.
+func (c clusterCompatibility) checkImage(image string) error {
+	var available []string
+	for _, i := range c.version.Images {
+		if image == i.ImageName || image == i.Name || image == i.OpenstackId {
+			return nil
+		}
+		available = append(available, i.ImageName)
+	}
+	return fmt.Errorf("image %q is not available for cluster version %s, available images: %v",
+		image, c.version.Version, available)
+}
+
+func (c *compatibilityCatalog) checkNetworking(compatible clusterCompatibility, networkingId string) error {
+	for _, n := range compatible.networking {
+		if n.ID == networkingId {
+			return nil
+		}
+	}
+	var available []string
+	for _, n := range compatible.networking {
+		available = append(available, fmt.Sprintf("%s %s (%s)", n.Name, n.Version, n.ID))
+	}
+	for _, n := range c.networking {
+		if n.ID == networkingId {
+			return fmt.Errorf("networking %s %s doesn't support cluster version %s, compatible networking: %v",
+				n.Name, n.Version, compatible.version.Version, available)
+		}
+	}
+	return fmt.Errorf("networking %q doesn't exist, compatible networking: %v", networkingId, available)
+}
+
+func (c *compatibilityCatalog) checkAddon(compatible clusterCompatibility, addon Addon) error {
+	var catalogAddon *ocp_client.ClusterAddon
+	for i := range c.addons {
+		if c.addons[i].Name == addon.Name {
+			catalogAddon = &c.addons[i]
+			break
+		}
+	}
+	if catalogAddon == nil {
+		return fmt.Errorf("addon %q doesn't exist", addon.Name)
+	}
+
+	var available []string
+	for _, a := range compatible.addons {
+		if a.Name != addon.Name {
+			continue
+		}
+		for _, release := range a.Releases {
+			if release.Version == addon.Version || versionsEqual(release.Version, addon.Version) {
+				return nil
+			}
+			available = append(available, release.Version)
+		}
+	}
+	for _, release := range catalogAddon.Releases {
+		if release.Version == addon.Version || versionsEqual(release.Version, addon.Version) {
+			return fmt.Errorf("addon %s %s doesn't support cluster version %s, compatible versions: %v",
+				addon.Name, addon.Version, compatible.version.Version, available)
+		}
+	}
+	return fmt.Errorf("addon %s has no release %s, compatible versions: %v", addon.Name, addon.Version, available)
+}
+
+// validateClusterCompatibility checks at plan time that the image, networking
+// and addons of a cluster can be combined with its version. It only runs for
+// new clusters or when one of them changes, so clusters on versions that left
+// the catalog can still be planned. The image and networking are only checked
+// for new clusters or when they change, and addons when they are added or
+// change version: an upgrade keeps them as they are. Unknown values are
+// skipped, and so is the whole check if the catalog can't be read.
+func validateClusterCompatibility(ctx context.Context, d *schema.ResourceDiff, client *ocp_client.Client) error {
+	if d.Id() != "" && !d.HasChanges("cluster_version", "image", "networking", "addons") {
+		return nil
+	}
+	if !d.NewValueKnown("cluster_version") {
+		return nil
+	}
+
+	catalog, err := getCompatibilityCatalog(ctx, client)
+	if err != nil {
+		tflog.Warn(ctx, "Can't read the catalog, skipping the compatibility check", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return nil
+	}
+
+	compatible, err := catalog.checkVersion(d.Get("cluster_version").(string))
+	if err != nil {
+		return err
+	}
+	if (d.Id() == "" || d.HasChange("image")) && d.NewValueKnown("image") {
+		if err := compatible.checkImage(d.Get("image").(string)); err != nil {
+			return err
+		}
+	}
+	if (d.Id() == "" || d.HasChange("networking")) && d.NewValueKnown("networking") {
+		if err := catalog.checkNetworking(compatible, d.Get("networking").(string)); err != nil {
+			return err
+		}
+	}
+	oldAddons, _ := d.GetChange("addons")
+	installed := make(map[Addon]bool)
+	if d.Id() != "" {
+		for _, addon := range getAddons(oldAddons.([]interface{})) {
+			installed[addon] = true
+		}
+	}
+	for i, addon := range getAddons(d.Get("addons").([]interface{})) {
+		if !d.NewValueKnown(fmt.Sprintf("addons.%d.name", i)) || !d.NewValueKnown(fmt.Sprintf("addons.%d.version", i)) {
+			continue
+		}
+		if installed[addon] {
+			continue
+		}
+		if err := catalog.checkAddon(compatible, addon); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -2,45 +2,177 @@ package onecloud
 
 import (
 	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/terraform-providers/terraform-provider-onecloud/internal/ocp_client"
 	"sync"
+	"time"
 )
 
-var (
-	cfgSingletone *Config
-	once          sync.Once
-)
-
-// Config contains all available configuration options.
+// Config contains all available configuration options. A Config is created
+// for every provider instance, so aliases don't share locks or limits.
 type Config struct {
-	ApiToken string
-	Region   string
-	Context  context.Context
-	lock     sync.Mutex
+	ApiToken                    string
+	Region                      string
+	ShowOperationProgress       bool
+	OperationPollDelay          time.Duration
+	OperationPollMinInterval    time.Duration
+	OperationPollMaxInterval    time.Duration
+	OperationPollBackoff        float64
+	CancelOperationsOnInterrupt bool
+	MaxConcurrentOperations     int
+	CatalogCacheTTL             time.Duration
+	ClusterCacheTTL             time.Duration
+	CatalogFile                 string
+	Context                     context.Context
+
+	client       *ocp_client.Client
+	clusters     *clusterCache
+	lock         sync.Mutex
+	clusterLocks map[string]chan struct{}
+	operations   chan struct{}
 }
 
 func getConfig(d *schema.ResourceData) (*Config, diag.Diagnostics) {
-	once.Do(func() {
-		cfgSingletone = &Config{
-			ApiToken: d.Get("api_token").(string),
+	config := &Config{
+		ApiToken:                    d.Get("api_token").(string),
+		ShowOperationProgress:       d.Get("show_operation_progress").(bool),
+		OperationPollDelay:          time.Duration(d.Get("operation_poll_delay").(int)) * time.Second,
+		OperationPollMinInterval:    time.Duration(d.Get("operation_poll_min_interval").(int)) * time.Second,
+		OperationPollMaxInterval:    time.Duration(d.Get("operation_poll_max_interval").(int)) * time.Second,
+		OperationPollBackoff:        d.Get("operation_poll_backoff_factor").(float64),
+		CancelOperationsOnInterrupt: d.Get("cancel_operations_on_interrupt").(bool),
+		MaxConcurrentOperations:     d.Get("max_concurrent_operations").(int),
+		CatalogCacheTTL:             time.Duration(d.Get("catalog_cache_ttl").(int)) * time.Second,
+		ClusterCacheTTL:             time.Duration(d.Get("cluster_cache_ttl").(int)) * time.Second,
+		CatalogFile:                 d.Get("catalog_file").(string),
+		clusterLocks:                make(map[string]chan struct{}),
+	}
+	if v, ok := d.GetOk("region"); ok {
+		config.Region = v.(string)
+	} else {
+		config.Region = ocp_client.UARegion
+	}
+	client, err := ocp_client.GetClient(config.ApiToken, config.Region)
+	if err != nil {
+		return nil, diag.FromErr(err)
+	}
+	client.CatalogTTL = config.CatalogCacheTTL
+	if config.CatalogFile != "" {
+		client.Snapshot, err = ocp_client.LoadCatalogSnapshot(config.CatalogFile)
+		if err != nil {
+			return nil, diag.FromErr(err)
 		}
-		if v, ok := d.GetOk("region"); ok {
-			cfgSingletone.Region = v.(string)
-		} else {
-			cfgSingletone.Region = ocp_client.UARegion
+		if client.Snapshot.Region != config.Region {
+			return nil, diag.Errorf("catalog snapshot %s is for region %q, the provider uses %q",
+				config.CatalogFile, client.Snapshot.Region, config.Region)
 		}
-	})
+	}
+	config.client = client
+	config.clusters = newClusterCache(config.ClusterCacheTTL)
+	if config.MaxConcurrentOperations > 0 {
+		config.operations = make(chan struct{}, config.MaxConcurrentOperations)
+	}
 
-	return cfgSingletone, nil
+	if config.OperationPollMaxInterval < config.OperationPollMinInterval {
+		return nil, diag.Errorf("operation_poll_max_interval can't be less than operation_poll_min_interval")
+	}
+	return config, nil
+}
+
+// startMutation serializes mutations of a cluster and its node pools and takes
+// one of the max_concurrent_operations slots. An empty clusterId only takes a
+// slot. The returned function releases both and drops the cached cluster.
+func (c *Config) startMutation(ctx context.Context, clusterId string) (func(), error) {
+	var clusterLock chan struct{}
+	if clusterId != "" {
+		c.lock.Lock()
+		clusterLock = c.clusterLocks[clusterId]
+		if clusterLock == nil {
+			clusterLock = make(chan struct{}, 1)
+			c.clusterLocks[clusterId] = clusterLock
+		}
+		c.lock.Unlock()
+
+		tflog.Debug(ctx, "Waiting for the cluster lock", map[string]interface{}{"cluster_id": clusterId})
+		select {
+		case clusterLock <- struct{}{}:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("waiting for other changes of cluster %s, %w", clusterId, ctx.Err())
+		}
+	}
+
+	if c.operations != nil {
+		select {
+		case c.operations <- struct{}{}:
+		case <-ctx.Done():
+			if clusterLock != nil {
+				<-clusterLock
+			}
+			return nil, fmt.Errorf("waiting for a free operation slot, %w", ctx.Err())
+		}
+	}
+
+	return func() {
+		if clusterId != "" {
+			c.clusters.invalidate(clusterId)
+		}
+		if c.operations != nil {
+			<-c.operations
+		}
+		if clusterLock != nil {
+			<-clusterLock
+		}
+	}, nil
 }
 
 func getOCPClient(meta interface{}) (*ocp_client.Client, error) {
 	config := meta.(*Config)
-	client, err := ocp_client.GetClient(config.ApiToken, config.Region)
+	if config.client == nil {
+		return nil, fmt.Errorf("provider is not configured")
+	}
+	return config.client, nil
+}
+
+// getCachedCluster returns the cluster through the refresh cache, nil if it
+// doesn't exist.
+func getCachedCluster(ctx context.Context, meta interface{}, clusterId string) (map[string]interface{}, error) {
+	config := meta.(*Config)
+	return config.clusters.get(ctx, config.client, clusterId)
+}
+
+// catalogSnapshotCheckTimeout limits how long configure waits for the API when
+// comparing the catalog snapshot, so offline runs aren't slowed down.
+const catalogSnapshotCheckTimeout = 10 * time.Second
+
+// checkCatalogSnapshot warns if the catalog snapshot differs from the live
+// catalogs. If the API can't be reached the snapshot is used silently.
+func checkCatalogSnapshot(ctx context.Context, config *Config) diag.Diagnostics {
+	snapshot := config.client.Snapshot
+	if snapshot == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, catalogSnapshotCheckTimeout)
+	defer cancel()
+	live, err := config.client.ExportCatalog(ctx)
 	if err != nil {
-		return nil, err
+		tflog.Info(ctx, "Can't compare the catalog snapshot with the API", map[string]interface{}{
+			"catalog_file": config.CatalogFile,
+			"error":        err.Error(),
+		})
+		return nil
+	}
+	if snapshot.Equal(live) {
+		return nil
 	}
-	return client, nil
+	return diag.Diagnostics{{
+		Severity: diag.Warning,
+		Summary:  "Catalog snapshot is stale",
+		Detail: fmt.Sprintf("The catalogs in %s, created at %s, differ from the ones the API returns now. "+
+			"Data sources read the snapshot, export it again with ocp-catalog to pick up the changes.",
+			config.CatalogFile, snapshot.CreatedAt.Format(time.RFC3339)),
+	}}
 }
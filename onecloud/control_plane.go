@@ -0,0 +1,135 @@
+package onecloud
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/terraform-providers/terraform-provider-onecloud/internal/ocp_client"
+	"time"
+)
+
+const controlPlaneStateUnhealthy = "unhealthy"
+const controlPlaneStateHealthy = "healthy"
+
+// rollControlPlane replaces the control plane nodes with nodes of the given
+// flavor one at a time. Before every replacement and after the last one it
+// waits until etcd has a healthy quorum with a member on every control plane
+// node and all control plane nodes are ready, so no more than one member is
+// missing at any time.
+func rollControlPlane(ctx context.Context, config *Config, client *ocp_client.Client, clusterId, flavorId string, deadline time.Time) diag.Diagnostics {
+	cluster, err := client.GetCluster(ctx, clusterId)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if cluster == nil {
+		return diag.Errorf("cluster %s doesn't exist", clusterId)
+	}
+	controlNodes, _ := cluster["control_nodes"].([]interface{})
+	var nodeIds []string
+	for _, n := range controlNodes {
+		if node, ok := n.(map[string]interface{}); ok {
+			if id, ok := node["id"].(string); ok {
+				nodeIds = append(nodeIds, id)
+			}
+		}
+	}
+
+	var diags diag.Diagnostics
+	for i, nodeId := range nodeIds {
+		err := waitForControlPlaneHealthy(ctx, config, client, clusterId, len(nodeIds), deadline)
+		if err != nil {
+			return append(diags, diag.Errorf("control plane isn't healthy before replacing node %d/%d %s: %s",
+				i+1, len(nodeIds), nodeId, err)...)
+		}
+
+		var resp map[string]interface{}
+		err = retryOnConflict(ctx, config, *client, time.Until(deadline), func() (err error) {
+			resp, err = client.ReplaceControlNode(ctx, clusterId, nodeId, map[string]interface{}{"flavor_id": flavorId})
+			return err
+		})
+		if err != nil {
+			return append(diags, diag.FromErr(err)...)
+		}
+		if operationId, ok := resp["operation_id"].(string); ok {
+			_, progress, waitErr := waitForOperationSuccess(ctx, config, *client, operationId, time.Until(deadline))
+			diags = append(diags, progress...)
+			if waitErr != nil {
+				return append(diags, operationDiagnostics(waitErr)...)
+			}
+		}
+		tflog.Info(ctx, "Control plane node replaced", map[string]interface{}{
+			"cluster_id": clusterId,
+			"node_id":    nodeId,
+			"step":       fmt.Sprintf("%d/%d", i+1, len(nodeIds)),
+		})
+	}
+
+	err = waitForControlPlaneHealthy(ctx, config, client, clusterId, len(nodeIds), deadline)
+	if err != nil {
+		return append(diags, diag.Errorf("control plane isn't healthy after the replacement: %s", err)...)
+	}
+
+	// Record the flavor on the cluster for nodes added later. Every node runs
+	// it already, so the update doesn't replace any.
+	var resp map[string]interface{}
+	err = retryOnConflict(ctx, config, *client, time.Until(deadline), func() (err error) {
+		resp, err = client.UpdateCluster(ctx, clusterId, map[string]interface{}{"master_flavor_id": flavorId})
+		return err
+	})
+	if err != nil {
+		return append(diags, diag.FromErr(err)...)
+	}
+	if operationId, ok := resp["operation_id"].(string); ok {
+		_, progress, waitErr := waitForOperationSuccess(ctx, config, *client, operationId, time.Until(deadline))
+		diags = append(diags, progress...)
+		if waitErr != nil {
+			return append(diags, operationDiagnostics(waitErr)...)
+		}
+	}
+	return diags
+}
+
+// waitForControlPlaneHealthy waits until etcd reports a healthy quorum with
+// at least members members and every control plane node is ready.
+func waitForControlPlaneHealthy(ctx context.Context, config *Config, client *ocp_client.Client, clusterId string, members int, deadline time.Time) error {
+	return pollUntil(ctx, config, deadline, controlPlaneStateUnhealthy, controlPlaneStateHealthy, func() (bool, error) {
+		health, err := client.GetClusterHealth(ctx, clusterId)
+		if err != nil {
+			return false, err
+		}
+		if !controlPlaneHealthy(health, members) {
+			return false, nil
+		}
+
+		cluster, err := client.GetCluster(ctx, clusterId)
+		if err != nil {
+			return false, err
+		}
+		controlNodes, _ := cluster["control_nodes"].([]interface{})
+		if len(controlNodes) < members {
+			return false, nil
+		}
+		for _, n := range controlNodes {
+			if node, ok := n.(map[string]interface{}); !ok || node["ready"] != true {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+}
+
+// controlPlaneHealthy reports whether the health payload shows a healthy
+// etcd quorum with at least members healthy members and healthy control
+// plane components.
+func controlPlaneHealthy(health map[string]interface{}, members int) bool {
+	etcd, _ := health["etcd"].(map[string]interface{})
+	if etcd["healthy"] != true {
+		return false
+	}
+	if healthyMembers, _ := etcd["healthy_members"].(float64); int(healthyMembers) < members {
+		return false
+	}
+	controlPlane, _ := health["control_plane"].(map[string]interface{})
+	return controlPlane["healthy"] == true
+}
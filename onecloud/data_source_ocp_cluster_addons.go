@@ -3,9 +3,11 @@ package onecloud
 import (
 	"context"
 	"encoding/json"
+	"github.com/hashicorp/go-version"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/terraform-providers/terraform-provider-onecloud/internal/ocp_client"
+	"sort"
 )
 
 func dataSourceClusterAddons() *schema.Resource {
@@ -42,6 +44,11 @@ func dataSourceClusterAddons() *schema.Resource {
 										Type:     schema.TypeString,
 										Computed: true,
 									},
+									"cluster_versions": {
+										Type:     schema.TypeList,
+										Computed: true,
+										Elem:     &schema.Schema{Type: schema.TypeString},
+									},
 								},
 							},
 						},
@@ -57,15 +64,35 @@ func dataSourceClusterAddons() *schema.Resource {
 							Type:     schema.TypeString,
 							Optional: true,
 						},
+						"version": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"cluster_version": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
 					},
 				},
 			},
+			"version_constraint": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateVersionConstraint,
+			},
+			"most_recent": {
+				Type:     schema.TypeBool,
+				Optional: true,
+			},
 		},
 	}
 }
 
 type addonSearchFilter struct {
-	name string
+	name           string
+	version        string
+	clusterVersion string
+	constraint     version.Constraints
 }
 
 func dataSourceClusterAddonsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -85,8 +112,25 @@ func dataSourceClusterAddonsRead(ctx context.Context, d *schema.ResourceData, me
 	}
 
 	filter := getAddonFilterMap(d)
+	if v, ok := d.GetOk("version_constraint"); ok {
+		filter.constraint, err = version.NewConstraint(v.(string))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
 	clusterAddons = filterClusterAddons(clusterAddons, filter)
 
+	if d.Get("most_recent").(bool) {
+		if len(clusterAddons) == 0 {
+			return diag.Errorf("no addon release matches the filter")
+		}
+		for i := range clusterAddons {
+			if len(clusterAddons[i].Releases) > 1 {
+				clusterAddons[i].Releases = clusterAddons[i].Releases[:1]
+			}
+		}
+	}
+
 	addonsObj, err := serializeClusterAddons(clusterAddons)
 	if err != nil {
 		return diag.FromErr(err)
@@ -119,24 +163,55 @@ func getAddonFilterMap(d *schema.ResourceData) addonSearchFilter {
 		filter.name = name.(string)
 	}
 
+	version, ok := filterMap["version"]
+	if ok {
+		filter.version = version.(string)
+	}
+
+	clusterVersion, ok := filterMap["cluster_version"]
+	if ok {
+		filter.clusterVersion = clusterVersion.(string)
+	}
+
 	return filter
 }
 
+// filterClusterAddons keeps the matching addons and their matching releases,
+// sorted newest first. Addons left without a release are dropped when a
+// release filter is set.
 func filterClusterAddons(addons []ocp_client.ClusterAddon, filter addonSearchFilter) []ocp_client.ClusterAddon {
 	var filteredAddons []ocp_client.ClusterAddon
-
-	if filter.name == "" {
-		return addons
-	}
+	filterReleases := filter.version != "" || filter.clusterVersion != "" || filter.constraint != nil
 
 	for _, addon := range addons {
-		if addon.Name == filter.name {
-			filteredAddons = append(filteredAddons, addon)
+		if filter.name != "" && addon.Name != filter.name {
+			continue
+		}
+		var releases []ocp_client.Release
+		for _, release := range addon.Releases {
+			if (filter.version == "" || release.Version == filter.version) &&
+				(filter.clusterVersion == "" || supportsClusterVersion(release.ClusterVersions, filter.clusterVersion)) &&
+				(filter.constraint == nil || versionMatches(release.Version, filter.constraint)) {
+				releases = append(releases, release)
+			}
 		}
+		if filterReleases && len(releases) == 0 {
+			continue
+		}
+		sortReleases(releases)
+		addon.Releases = releases
+		filteredAddons = append(filteredAddons, addon)
 	}
 	return filteredAddons
 }
 
+// sortReleases orders addon releases semantically, the newest first.
+func sortReleases(releases []ocp_client.Release) {
+	sort.SliceStable(releases, func(i, j int) bool {
+		return compareVersions(releases[i].Version, releases[j].Version) > 0
+	})
+}
+
 func serializeClusterAddons(addons []ocp_client.ClusterAddon) ([]map[string]interface{}, error) {
 	result := make([]map[string]interface{}, 0)
 	for _, obj := range addons {
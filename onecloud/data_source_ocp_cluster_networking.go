@@ -3,9 +3,11 @@ package onecloud
 import (
 	"context"
 	"encoding/json"
+	"github.com/hashicorp/go-version"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/terraform-providers/terraform-provider-onecloud/internal/ocp_client"
+	"sort"
 )
 
 func dataSourceClusterNetworking() *schema.Resource {
@@ -29,6 +31,11 @@ func dataSourceClusterNetworking() *schema.Resource {
 							Type:     schema.TypeString,
 							Computed: true,
 						},
+						"cluster_versions": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
 					},
 				},
 			},
@@ -45,16 +52,31 @@ func dataSourceClusterNetworking() *schema.Resource {
 							Type:     schema.TypeString,
 							Optional: true,
 						},
+						"cluster_version": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
 					},
 				},
 			},
+			"version_constraint": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateVersionConstraint,
+			},
+			"most_recent": {
+				Type:     schema.TypeBool,
+				Optional: true,
+			},
 		},
 	}
 }
 
 type networkingSearchFilter struct {
-	name    string
-	version string
+	name           string
+	version        string
+	clusterVersion string
+	constraint     version.Constraints
 }
 
 func dataSourceNetworkingRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -74,7 +96,21 @@ func dataSourceNetworkingRead(ctx context.Context, d *schema.ResourceData, meta
 	}
 
 	filter := getNetworkingFilterMap(d)
+	if v, ok := d.GetOk("version_constraint"); ok {
+		filter.constraint, err = version.NewConstraint(v.(string))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
 	networking = filterNetworking(networking, filter)
+	sortNetworking(networking)
+
+	if d.Get("most_recent").(bool) {
+		if len(networking) == 0 {
+			return diag.Errorf("no networking matches the filter")
+		}
+		networking = newestNetworking(networking)
+	}
 
 	networkingObj, err := serializeNetworking(networking)
 
@@ -114,19 +150,26 @@ func getNetworkingFilterMap(d *schema.ResourceData) networkingSearchFilter {
 		filter.name = name.(string)
 	}
 
+	clusterVersion, ok := filterMap["cluster_version"]
+	if ok {
+		filter.clusterVersion = clusterVersion.(string)
+	}
+
 	return filter
 }
 
 func filterNetworking(networking []ocp_client.Networking, filter networkingSearchFilter) []ocp_client.Networking {
 	var filteredNetworking []ocp_client.Networking
 
-	if filter.version == "" && filter.name == "" {
+	if filter.version == "" && filter.name == "" && filter.clusterVersion == "" && filter.constraint == nil {
 		return networking
 	}
 
 	for _, n := range networking {
 		if (filter.version == "" || n.Version == filter.version) &&
-			(filter.name == "" || n.Name == filter.name) {
+			(filter.name == "" || n.Name == filter.name) &&
+			(filter.clusterVersion == "" || supportsClusterVersion(n.ClusterVersions, filter.clusterVersion)) &&
+			(filter.constraint == nil || versionMatches(n.Version, filter.constraint)) {
 			filteredNetworking = append(filteredNetworking, n)
 		}
 	}
@@ -134,6 +177,27 @@ func filterNetworking(networking []ocp_client.Networking, filter networkingSearc
 	return filteredNetworking
 }
 
+// sortNetworking orders networking semantically by version, the newest first.
+func sortNetworking(networking []ocp_client.Networking) {
+	sort.SliceStable(networking, func(i, j int) bool {
+		return compareVersions(networking[i].Version, networking[j].Version) > 0
+	})
+}
+
+// newestNetworking keeps the newest version of every network name, networking
+// must be sorted newest first.
+func newestNetworking(networking []ocp_client.Networking) []ocp_client.Networking {
+	var newest []ocp_client.Networking
+	seen := make(map[string]bool)
+	for _, n := range networking {
+		if !seen[n.Name] {
+			seen[n.Name] = true
+			newest = append(newest, n)
+		}
+	}
+	return newest
+}
+
 func serializeNetworking(networking []ocp_client.Networking) ([]map[string]interface{}, error) {
 	result := make([]map[string]interface{}, 0)
 	for _, obj := range networking {
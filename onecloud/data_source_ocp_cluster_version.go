@@ -3,9 +3,11 @@ package onecloud
 import (
 	"context"
 	"encoding/json"
+	"github.com/hashicorp/go-version"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/terraform-providers/terraform-provider-onecloud/internal/ocp_client"
+	"sort"
 )
 
 func dataSourceClusterVersion() *schema.Resource {
@@ -25,6 +27,29 @@ func dataSourceClusterVersion() *schema.Resource {
 							Type:     schema.TypeString,
 							Computed: true,
 						},
+						"default": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"deprecated": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"available_upgrades": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+							},
+						},
+						"release_date": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"end_of_support": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
 						"images": {
 							Type:     schema.TypeList,
 							Computed: true,
@@ -46,6 +71,10 @@ func dataSourceClusterVersion() *schema.Resource {
 										Type:     schema.TypeString,
 										Computed: true,
 									},
+									"default": {
+										Type:     schema.TypeBool,
+										Computed: true,
+									},
 								},
 							},
 						},
@@ -72,14 +101,40 @@ func dataSourceClusterVersion() *schema.Resource {
 					},
 				},
 			},
+			"version_constraint": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateVersionConstraint,
+			},
+			"most_recent": {
+				Type:     schema.TypeBool,
+				Optional: true,
+			},
+			"version": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"version_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"default_image_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"default_image_name": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
 		},
 	}
 }
 
 type versionSearchFilter struct {
-	version   string
-	imageName string
-	osDistro  string
+	version    string
+	imageName  string
+	osDistro   string
+	constraint version.Constraints
 }
 
 func dataSourceClusterVersionRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -99,7 +154,30 @@ func dataSourceClusterVersionRead(ctx context.Context, d *schema.ResourceData, m
 	}
 
 	filter := getVersionFilterMap(d)
+	if v, ok := d.GetOk("version_constraint"); ok {
+		filter.constraint, err = version.NewConstraint(v.(string))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
 	clusterVersions = filterClusterVersion(clusterVersions, filter)
+	sortClusterVersions(clusterVersions)
+
+	if d.Get("most_recent").(bool) {
+		mostRecent := mostRecentClusterVersion(clusterVersions)
+		if mostRecent == nil {
+			return diag.Errorf("no cluster version that isn't deprecated matches the filter")
+		}
+		clusterVersions = []ocp_client.ClusterVersion{*mostRecent}
+	}
+	if len(clusterVersions) == 1 {
+		err = setSelectedClusterVersion(d, clusterVersions[0])
+	} else {
+		err = setSelectedClusterVersion(d, ocp_client.ClusterVersion{})
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
 
 	clusterObj, err := serializeClusterVersions(clusterVersions)
 	if err != nil {
@@ -149,12 +227,13 @@ func getVersionFilterMap(d *schema.ResourceData) versionSearchFilter {
 func filterClusterVersion(versions []ocp_client.ClusterVersion, filter versionSearchFilter) []ocp_client.ClusterVersion {
 	var filteredVersions []ocp_client.ClusterVersion
 
-	if filter.version == "" && filter.imageName == "" && filter.osDistro == "" {
+	if filter.version == "" && filter.imageName == "" && filter.osDistro == "" && filter.constraint == nil {
 		return versions
 	}
 
 	for _, version := range versions {
-		if filter.version == "" || version.Version == filter.version {
+		if (filter.version == "" || version.Version == filter.version) &&
+			(filter.constraint == nil || versionMatches(version.Version, filter.constraint)) {
 			var filteredImages []ocp_client.Image
 			for _, image := range version.Images {
 				if (filter.imageName == "" || image.Name == filter.imageName) &&
@@ -170,6 +249,46 @@ func filterClusterVersion(versions []ocp_client.ClusterVersion, filter versionSe
 	return filteredVersions
 }
 
+// sortClusterVersions orders versions semantically, the newest first.
+func sortClusterVersions(versions []ocp_client.ClusterVersion) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		return compareVersions(versions[i].Version, versions[j].Version) > 0
+	})
+}
+
+// mostRecentClusterVersion returns the newest version that isn't deprecated
+// from versions sorted by sortClusterVersions.
+func mostRecentClusterVersion(versions []ocp_client.ClusterVersion) *ocp_client.ClusterVersion {
+	for i := range versions {
+		if !versions[i].Deprecated {
+			return &versions[i]
+		}
+	}
+	return nil
+}
+
+// setSelectedClusterVersion sets the attributes of a single selected version,
+// an empty version clears them.
+func setSelectedClusterVersion(d *schema.ResourceData, clusterVersion ocp_client.ClusterVersion) error {
+	imageId, imageName := "", ""
+	if image := clusterVersion.DefaultImage(); image != nil {
+		imageId, imageName = image.OpenstackId, image.ImageName
+	}
+	err := d.Set("version", clusterVersion.Version)
+	if err != nil {
+		return err
+	}
+	err = d.Set("version_id", clusterVersion.ID)
+	if err != nil {
+		return err
+	}
+	err = d.Set("default_image_id", imageId)
+	if err != nil {
+		return err
+	}
+	return d.Set("default_image_name", imageName)
+}
+
 func serializeClusterVersions(versions []ocp_client.ClusterVersion) ([]map[string]interface{}, error) {
 	result := make([]map[string]interface{}, 0)
 	for _, obj := range versions {
@@ -0,0 +1,172 @@
+package onecloud
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func addonVersionsSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeList,
+		Computed: true,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"name": {
+					Type:     schema.TypeString,
+					Computed: true,
+				},
+				"versions": {
+					Type:     schema.TypeList,
+					Computed: true,
+					Elem:     &schema.Schema{Type: schema.TypeString},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceCompatibility() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceCompatibilityRead,
+		Schema: map[string]*schema.Schema{
+			"cluster_version": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"versions": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"cluster_version": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"cluster_version_id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"deprecated": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"image_names": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+						"networking_ids": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+						"addon_versions": addonVersionsSchema(),
+					},
+				},
+			},
+			"image_names": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"networking_ids": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"addon_versions": addonVersionsSchema(),
+		},
+	}
+}
+
+func dataSourceCompatibilityRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, err := getOCPClient(meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	catalog, err := getCompatibilityCatalog(ctx, client)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	var compatibility []clusterCompatibility
+	if v, ok := d.GetOk("cluster_version"); ok {
+		compatible, err := catalog.checkVersion(v.(string))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		compatibility = append(compatibility, compatible)
+	} else {
+		for _, clusterVersion := range catalog.versions {
+			compatibility = append(compatibility, catalog.forVersion(clusterVersion))
+		}
+	}
+
+	var versionIds []string
+	versions := make([]map[string]interface{}, 0, len(compatibility))
+	for _, compatible := range compatibility {
+		versionIds = append(versionIds, compatible.version.ID)
+		versions = append(versions, flattenCompatibility(compatible))
+	}
+	if err := d.Set("versions", versions); err != nil {
+		return diag.FromErr(err)
+	}
+
+	selected := map[string]interface{}{}
+	if len(versions) == 1 {
+		selected = versions[0]
+	}
+	err = d.Set("image_names", selected["image_names"])
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	err = d.Set("networking_ids", selected["networking_ids"])
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	err = d.Set("addon_versions", selected["addon_versions"])
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	checksum, err := stringListChecksum(versionIds)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(checksum)
+	return nil
+}
+
+// flattenCompatibility lists the networking and addon releases of a version,
+// both newest first.
+func flattenCompatibility(compatible clusterCompatibility) map[string]interface{} {
+	imageNames := make([]string, 0, len(compatible.version.Images))
+	for _, image := range compatible.version.Images {
+		imageNames = append(imageNames, image.ImageName)
+	}
+	networkingIds := make([]string, 0, len(compatible.networking))
+	for _, n := range compatible.networking {
+		networkingIds = append(networkingIds, n.ID)
+	}
+	addonVersions := make([]map[string]interface{}, 0, len(compatible.addons))
+	for _, addon := range compatible.addons {
+		releases := make([]string, 0, len(addon.Releases))
+		for _, release := range addon.Releases {
+			releases = append(releases, release.Version)
+		}
+		addonVersions = append(addonVersions, map[string]interface{}{
+			"name":     addon.Name,
+			"versions": releases,
+		})
+	}
+	return map[string]interface{}{
+		"cluster_version":    compatible.version.Version,
+		"cluster_version_id": compatible.version.ID,
+		"deprecated":         compatible.version.Deprecated,
+		"image_names":        imageNames,
+		"networking_ids":     networkingIds,
+		"addon_versions":     addonVersions,
+	}
+}
@@ -7,7 +7,9 @@ import (
 	"fmt"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/terraform-providers/terraform-provider-onecloud/internal/ocp_client"
+	"regexp"
 	"sort"
 	"strings"
 )
@@ -72,6 +74,37 @@ func dataSourceFlavor() *schema.Resource {
 							Type:     schema.TypeString,
 							Computed: true,
 						},
+						"extra_specs": {
+							Type:     schema.TypeMap,
+							Computed: true,
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+							},
+						},
+						"dedicated_cpu": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"numa": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"huge_pages": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"local_nvme": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"gpu": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"network_bandwidth_mbps": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
 						"region": {
 							Type:     schema.TypeString,
 							Computed: true,
@@ -115,18 +148,132 @@ func dataSourceFlavor() *schema.Resource {
 							Type:     schema.TypeInt,
 							Optional: true,
 						},
+						"min_vcpus": {
+							Type:     schema.TypeInt,
+							Optional: true,
+						},
+						"max_vcpus": {
+							Type:     schema.TypeInt,
+							Optional: true,
+						},
+						"min_memory_gb": {
+							Type:     schema.TypeFloat,
+							Optional: true,
+						},
+						"max_memory_gb": {
+							Type:     schema.TypeFloat,
+							Optional: true,
+						},
+						"min_root_gb": {
+							Type:     schema.TypeInt,
+							Optional: true,
+						},
+						"max_root_gb": {
+							Type:     schema.TypeInt,
+							Optional: true,
+						},
+						"name_regex": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validation.StringIsValidRegExp,
+						},
+						"exclude_out_of_stock": {
+							Type:     schema.TypeBool,
+							Optional: true,
+						},
+						"flavor_group": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"region": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"cluster_template": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"extra_spec": {
+							Type:     schema.TypeSet,
+							Optional: true,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"key": {
+										Type:     schema.TypeString,
+										Required: true,
+									},
+									"value": {
+										Type:     schema.TypeString,
+										Optional: true,
+									},
+								},
+							},
+						},
+						"dedicated_cpu": {
+							Type:     schema.TypeBool,
+							Optional: true,
+						},
+						"numa": {
+							Type:     schema.TypeBool,
+							Optional: true,
+						},
+						"huge_pages": {
+							Type:     schema.TypeBool,
+							Optional: true,
+						},
+						"local_nvme": {
+							Type:     schema.TypeBool,
+							Optional: true,
+						},
+						"gpu": {
+							Type:     schema.TypeBool,
+							Optional: true,
+						},
+						"min_network_bandwidth_mbps": {
+							Type:     schema.TypeInt,
+							Optional: true,
+						},
 					},
 				},
 			},
+			"sort_by": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice([]string{FlavorSortVcpus, FlavorSortMemory, FlavorSortName}, false),
+			},
+			"limit": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(1),
+			},
 		},
 	}
 }
 
+const FlavorSortVcpus = "vcpus"
+const FlavorSortMemory = "memory"
+const FlavorSortName = "name"
+
 type flavorSearchFilter struct {
-	vcpus    int
-	memoryGb float64
-	memoryMb int
-	rootGb   int
+	vcpus             int
+	memoryGb          float64
+	memoryMb          int
+	rootGb            int
+	minVcpus          int
+	maxVcpus          int
+	minMemoryGb       float64
+	maxMemoryGb       float64
+	minRootGb         int
+	maxRootGb         int
+	nameRegex         *regexp.Regexp
+	excludeOutOfStock bool
+	flavorGroup       string
+	region            string
+	clusterTemplate   string
+	// extraSpecs must all be present, an empty value matches any value.
+	extraSpecs              map[string]string
+	requiredCapabilities    flavorCapabilities
+	minNetworkBandwidthMbps int
 }
 
 func dataSourceFlavorRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -145,8 +292,17 @@ func dataSourceFlavorRead(ctx context.Context, d *schema.ResourceData, meta inte
 		flavorsIds = append(flavorsIds, flavor.ID)
 	}
 
-	filter := getFlavorFilterMap(d)
-	flavors = filterFlavor(flavors, filter)
+	filters, err := getFlavorFilters(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	flavors = filterFlavors(flavors, filters)
+	if sortBy, ok := d.GetOk("sort_by"); ok {
+		sortFlavors(flavors, sortBy.(string))
+	}
+	if limit, ok := d.GetOk("limit"); ok && len(flavors) > limit.(int) {
+		flavors = flavors[:limit.(int)]
+	}
 
 	flavorsObj, err := serializeFlavors(flavors)
 	if err != nil {
@@ -164,57 +320,152 @@ func dataSourceFlavorRead(ctx context.Context, d *schema.ResourceData, meta inte
 	return nil
 }
 
-func getFlavorFilterMap(d *schema.ResourceData) flavorSearchFilter {
-	var filterMap map[string]interface{}
-	filter := flavorSearchFilter{}
-
-	if filterSet, ok := d.GetOk("filter"); ok {
-		if filterSet.(*schema.Set).Len() == 0 {
-			return filter
-		}
-		filterMap = filterSet.(*schema.Set).List()[0].(map[string]interface{})
+// getFlavorFilters returns a filter for every filter block.
+func getFlavorFilters(d *schema.ResourceData) ([]flavorSearchFilter, error) {
+	var filters []flavorSearchFilter
+	filterSet, ok := d.GetOk("filter")
+	if !ok {
+		return filters, nil
 	}
 
-	vcpus, ok := filterMap["vcpus"]
-	if ok {
-		filter.vcpus = vcpus.(int)
+	for _, v := range filterSet.(*schema.Set).List() {
+		filterMap, _ := v.(map[string]interface{})
+		filter := flavorSearchFilter{
+			vcpus:             filterMap["vcpus"].(int),
+			memoryGb:          filterMap["memory_gb"].(float64),
+			memoryMb:          filterMap["memory_mb"].(int),
+			rootGb:            filterMap["root_gb"].(int),
+			minVcpus:          filterMap["min_vcpus"].(int),
+			maxVcpus:          filterMap["max_vcpus"].(int),
+			minMemoryGb:       filterMap["min_memory_gb"].(float64),
+			maxMemoryGb:       filterMap["max_memory_gb"].(float64),
+			minRootGb:         filterMap["min_root_gb"].(int),
+			maxRootGb:         filterMap["max_root_gb"].(int),
+			excludeOutOfStock: filterMap["exclude_out_of_stock"].(bool),
+			flavorGroup:       filterMap["flavor_group"].(string),
+			region:            filterMap["region"].(string),
+			clusterTemplate:   filterMap["cluster_template"].(string),
+			extraSpecs:        map[string]string{},
+			requiredCapabilities: flavorCapabilities{
+				DedicatedCpu: filterMap["dedicated_cpu"].(bool),
+				Numa:         filterMap["numa"].(bool),
+				HugePages:    filterMap["huge_pages"].(bool),
+				LocalNvme:    filterMap["local_nvme"].(bool),
+				Gpu:          filterMap["gpu"].(bool),
+			},
+			minNetworkBandwidthMbps: filterMap["min_network_bandwidth_mbps"].(int),
+		}
+		for _, spec := range filterMap["extra_spec"].(*schema.Set).List() {
+			specMap := spec.(map[string]interface{})
+			filter.extraSpecs[specMap["key"].(string)] = specMap["value"].(string)
+		}
+		if nameRegex := filterMap["name_regex"].(string); nameRegex != "" {
+			re, err := regexp.Compile(nameRegex)
+			if err != nil {
+				return nil, fmt.Errorf("invalid name_regex %q, %w", nameRegex, err)
+			}
+			filter.nameRegex = re
+		}
+		filters = append(filters, filter)
 	}
 
-	memoryGb, ok := filterMap["memory_gb"]
-	if ok {
-		filter.memoryGb = memoryGb.(float64)
-	}
+	return filters, nil
+}
 
-	memoryMb, ok := filterMap["memory_mb"]
-	if ok {
-		filter.memoryMb = memoryMb.(int)
+// filterFlavors keeps the flavors matching any of the filters, all flavors if
+// there are no filters.
+func filterFlavors(flavors []ocp_client.Flavor, filters []flavorSearchFilter) []ocp_client.Flavor {
+	if len(filters) == 0 {
+		return flavors
 	}
 
-	rootGb, ok := filterMap["root_gb"]
-	if ok {
-		filter.rootGb = rootGb.(int)
+	var filteredFlavors []ocp_client.Flavor
+	for _, f := range flavors {
+		for _, filter := range filters {
+			if flavorMatches(f, filter) {
+				filteredFlavors = append(filteredFlavors, f)
+				break
+			}
+		}
 	}
-
-	return filter
+	return filteredFlavors
 }
 
 func filterFlavor(flavors []ocp_client.Flavor, filter flavorSearchFilter) []ocp_client.Flavor {
-	var filteredFlavors []ocp_client.Flavor
+	return filterFlavors(flavors, []flavorSearchFilter{filter})
+}
 
-	if filter.vcpus == 0 && filter.rootGb == 0 && filter.memoryMb == 0 && filter.memoryGb == 0 {
-		return flavors
+func flavorMatches(f ocp_client.Flavor, filter flavorSearchFilter) bool {
+	return (filter.vcpus == 0 || f.Vcpus == filter.vcpus) &&
+		(filter.memoryGb == 0 || f.MemoryGb == filter.memoryGb) &&
+		(filter.memoryMb == 0 || f.MemoryMb == filter.memoryMb) &&
+		(filter.rootGb == 0 || f.RootGb == filter.rootGb) &&
+		(filter.minVcpus == 0 || f.Vcpus >= filter.minVcpus) &&
+		(filter.maxVcpus == 0 || f.Vcpus <= filter.maxVcpus) &&
+		(filter.minMemoryGb == 0 || f.MemoryGb >= filter.minMemoryGb) &&
+		(filter.maxMemoryGb == 0 || f.MemoryGb <= filter.maxMemoryGb) &&
+		(filter.minRootGb == 0 || f.RootGb >= filter.minRootGb) &&
+		(filter.maxRootGb == 0 || f.RootGb <= filter.maxRootGb) &&
+		(filter.nameRegex == nil || filter.nameRegex.MatchString(f.Name)) &&
+		(!filter.excludeOutOfStock || !f.OutOfStock) &&
+		(filter.flavorGroup == "" || (f.FlavorGroup != nil && *f.FlavorGroup == filter.flavorGroup)) &&
+		(filter.region == "" || f.Region == filter.region) &&
+		(filter.clusterTemplate == "" || containsString(f.AssignedClusterTemplates, filter.clusterTemplate)) &&
+		flavorPropertiesMatch(f, filter)
+}
+
+func flavorPropertiesMatch(f ocp_client.Flavor, filter flavorSearchFilter) bool {
+	if len(filter.extraSpecs) == 0 && filter.requiredCapabilities == (flavorCapabilities{}) &&
+		filter.minNetworkBandwidthMbps == 0 {
+		return true
 	}
 
-	for _, f := range flavors {
-		if (filter.vcpus == 0 || f.Vcpus == filter.vcpus) &&
-			(filter.memoryGb == 0 || f.MemoryGb == filter.memoryGb) &&
-			(filter.memoryMb == 0 || f.MemoryMb == filter.memoryMb) &&
-			(filter.rootGb == 0 || f.RootGb == filter.rootGb) {
-			filteredFlavors = append(filteredFlavors, f)
+	specs := f.ExtraSpecs()
+	for key, value := range filter.extraSpecs {
+		if v, ok := specs[key]; !ok || (value != "" && v != value) {
+			return false
 		}
 	}
+	capabilities := getFlavorCapabilities(specs)
+	required := filter.requiredCapabilities
+	return (!required.DedicatedCpu || capabilities.DedicatedCpu) &&
+		(!required.Numa || capabilities.Numa) &&
+		(!required.HugePages || capabilities.HugePages) &&
+		(!required.LocalNvme || capabilities.LocalNvme) &&
+		(!required.Gpu || capabilities.Gpu) &&
+		capabilities.NetworkBandwidthMbps >= filter.minNetworkBandwidthMbps
+}
 
-	return filteredFlavors
+// sortFlavors orders flavors by sort_by, ties are broken by the other sizes
+// and the name so the order is deterministic.
+func sortFlavors(flavors []ocp_client.Flavor, sortBy string) {
+	switch sortBy {
+	case FlavorSortVcpus:
+		sortFlavorsBySize(flavors)
+	case FlavorSortMemory:
+		sort.SliceStable(flavors, func(i, j int) bool {
+			if flavors[i].MemoryGb != flavors[j].MemoryGb {
+				return flavors[i].MemoryGb < flavors[j].MemoryGb
+			}
+			if flavors[i].Vcpus != flavors[j].Vcpus {
+				return flavors[i].Vcpus < flavors[j].Vcpus
+			}
+			return flavors[i].Name < flavors[j].Name
+		})
+	case FlavorSortName:
+		sort.SliceStable(flavors, func(i, j int) bool {
+			return flavors[i].Name < flavors[j].Name
+		})
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
 }
 
 func serializeFlavors(flavors []ocp_client.Flavor) ([]map[string]interface{}, error) {
@@ -229,6 +480,15 @@ func serializeFlavors(flavors []ocp_client.Flavor) ([]map[string]interface{}, er
 		if err != nil {
 			return nil, err
 		}
+		specs := obj.ExtraSpecs()
+		capabilities := getFlavorCapabilities(specs)
+		sObj["extra_specs"] = specs
+		sObj["dedicated_cpu"] = capabilities.DedicatedCpu
+		sObj["numa"] = capabilities.Numa
+		sObj["huge_pages"] = capabilities.HugePages
+		sObj["local_nvme"] = capabilities.LocalNvme
+		sObj["gpu"] = capabilities.Gpu
+		sObj["network_bandwidth_mbps"] = capabilities.NetworkBandwidthMbps
 		result = append(result, sObj)
 	}
 	return result, nil
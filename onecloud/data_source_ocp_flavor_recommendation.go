@@ -0,0 +1,252 @@
+package onecloud
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/terraform-providers/terraform-provider-onecloud/internal/ocp_client"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+func dataSourceFlavorRecommendation() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceFlavorRecommendationRead,
+		Schema: map[string]*schema.Schema{
+			"min_vcpus": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"min_memory_gb": {
+				Type:         schema.TypeFloat,
+				Optional:     true,
+				ValidateFunc: validation.FloatAtLeast(0),
+			},
+			"min_root_gb": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"flavor_group": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"name_regex": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringIsValidRegExp,
+			},
+			"cluster_template": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"extra_spec": {
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"key": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"value": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+					},
+				},
+			},
+			"dedicated_cpu": {
+				Type:     schema.TypeBool,
+				Optional: true,
+			},
+			"numa": {
+				Type:     schema.TypeBool,
+				Optional: true,
+			},
+			"huge_pages": {
+				Type:     schema.TypeBool,
+				Optional: true,
+			},
+			"local_nvme": {
+				Type:     schema.TypeBool,
+				Optional: true,
+			},
+			"gpu": {
+				Type:     schema.TypeBool,
+				Optional: true,
+			},
+			"min_network_bandwidth_mbps": {
+				Type:     schema.TypeInt,
+				Optional: true,
+			},
+			"exclude_out_of_stock": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+			"name": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"vcpus": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"memory_gb": {
+				Type:     schema.TypeFloat,
+				Computed: true,
+			},
+			"root_gb": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"out_of_stock": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func dataSourceFlavorRecommendationRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, err := getOCPClient(meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	flavors, err := client.Flavors(ctx)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	filter := flavorSearchFilter{
+		minVcpus:          d.Get("min_vcpus").(int),
+		minMemoryGb:       d.Get("min_memory_gb").(float64),
+		minRootGb:         d.Get("min_root_gb").(int),
+		flavorGroup:       d.Get("flavor_group").(string),
+		clusterTemplate:   d.Get("cluster_template").(string),
+		excludeOutOfStock: d.Get("exclude_out_of_stock").(bool),
+		extraSpecs:        map[string]string{},
+		requiredCapabilities: flavorCapabilities{
+			DedicatedCpu: d.Get("dedicated_cpu").(bool),
+			Numa:         d.Get("numa").(bool),
+			HugePages:    d.Get("huge_pages").(bool),
+			LocalNvme:    d.Get("local_nvme").(bool),
+			Gpu:          d.Get("gpu").(bool),
+		},
+		minNetworkBandwidthMbps: d.Get("min_network_bandwidth_mbps").(int),
+	}
+	for _, spec := range d.Get("extra_spec").(*schema.Set).List() {
+		specMap := spec.(map[string]interface{})
+		filter.extraSpecs[specMap["key"].(string)] = specMap["value"].(string)
+	}
+	if nameRegex := d.Get("name_regex").(string); nameRegex != "" {
+		filter.nameRegex, err = regexp.Compile(nameRegex)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	flavor, err := recommendFlavor(flavors, filter)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(flavor.ID)
+	err = d.Set("name", flavor.Name)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	err = d.Set("vcpus", flavor.Vcpus)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	err = d.Set("memory_gb", flavor.MemoryGb)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	err = d.Set("root_gb", flavor.RootGb)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	err = d.Set("out_of_stock", flavor.OutOfStock)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+// recommendFlavor returns the smallest flavor matching the filter, ordered by
+// sortFlavorsBySize. The pick_flavor function uses the same rule.
+func recommendFlavor(flavors []ocp_client.Flavor, filter flavorSearchFilter) (ocp_client.Flavor, error) {
+	matching := filterFlavor(flavors, filter)
+	if len(matching) == 0 {
+		return ocp_client.Flavor{}, fmt.Errorf("none of %d flavors matches the requirements: %s",
+			len(flavors), describeFlavorFilter(filter))
+	}
+
+	sortFlavorsBySize(matching)
+	return matching[0], nil
+}
+
+func describeFlavorFilter(filter flavorSearchFilter) string {
+	var requirements []string
+	if filter.minVcpus > 0 {
+		requirements = append(requirements, fmt.Sprintf("at least %d vCPU", filter.minVcpus))
+	}
+	if filter.minMemoryGb > 0 {
+		requirements = append(requirements, fmt.Sprintf("at least %g GB of memory", filter.minMemoryGb))
+	}
+	if filter.minRootGb > 0 {
+		requirements = append(requirements, fmt.Sprintf("at least %d GB of disk", filter.minRootGb))
+	}
+	if filter.flavorGroup != "" {
+		requirements = append(requirements, fmt.Sprintf("flavor group %q", filter.flavorGroup))
+	}
+	if filter.nameRegex != nil {
+		requirements = append(requirements, fmt.Sprintf("name matching %q", filter.nameRegex))
+	}
+	if filter.clusterTemplate != "" {
+		requirements = append(requirements, fmt.Sprintf("cluster template %q", filter.clusterTemplate))
+	}
+	extraSpecKeys := make([]string, 0, len(filter.extraSpecs))
+	for key := range filter.extraSpecs {
+		extraSpecKeys = append(extraSpecKeys, key)
+	}
+	sort.Strings(extraSpecKeys)
+	for _, key := range extraSpecKeys {
+		value := filter.extraSpecs[key]
+		if value == "" {
+			requirements = append(requirements, fmt.Sprintf("extra spec %s", key))
+		} else {
+			requirements = append(requirements, fmt.Sprintf("extra spec %s=%s", key, value))
+		}
+	}
+	capabilities := map[string]bool{
+		"dedicated CPUs": filter.requiredCapabilities.DedicatedCpu,
+		"NUMA":           filter.requiredCapabilities.Numa,
+		"huge pages":     filter.requiredCapabilities.HugePages,
+		"local NVMe":     filter.requiredCapabilities.LocalNvme,
+		"GPU":            filter.requiredCapabilities.Gpu,
+	}
+	for _, name := range []string{"dedicated CPUs", "NUMA", "huge pages", "local NVMe", "GPU"} {
+		if capabilities[name] {
+			requirements = append(requirements, name)
+		}
+	}
+	if filter.minNetworkBandwidthMbps > 0 {
+		requirements = append(requirements, fmt.Sprintf("at least %d Mbit/s of network bandwidth", filter.minNetworkBandwidthMbps))
+	}
+	if filter.excludeOutOfStock {
+		requirements = append(requirements, "in stock")
+	}
+	if len(requirements) == 0 {
+		return "none"
+	}
+	return strings.Join(requirements, ", ")
+}
@@ -0,0 +1,209 @@
+package onecloud
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/terraform-providers/terraform-provider-onecloud/internal/ocp_client"
+	"sort"
+	"time"
+)
+
+func dataSourceOperations() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceOperationsRead,
+		Schema: map[string]*schema.Schema{
+			"object_id": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"operation_type": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"status": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ValidateFunc: validation.StringInSlice([]string{
+					OperationStatusInProgress,
+					OperationStatusSucceeded,
+					OperationStatusFailed,
+					OperationStatusAborted,
+				}, false),
+			},
+			"started_after": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.IsRFC3339Time,
+			},
+			"started_before": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.IsRFC3339Time,
+			},
+			"operations": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"operation_type": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"status": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"object_id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"initiator": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"started_at": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"finished_at": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"error_message": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"steps": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"name": {
+										Type:     schema.TypeString,
+										Computed: true,
+									},
+									"status": {
+										Type:     schema.TypeString,
+										Computed: true,
+									},
+									"error": {
+										Type:     schema.TypeString,
+										Computed: true,
+									},
+									"started_at": {
+										Type:     schema.TypeString,
+										Computed: true,
+									},
+									"finished_at": {
+										Type:     schema.TypeString,
+										Computed: true,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceOperationsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	client, err := getOCPClient(meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	filter := ocp_client.OperationFilter{
+		ObjectID:      d.Get("object_id").(string),
+		OperationType: d.Get("operation_type").(string),
+		Status:        d.Get("status").(string),
+	}
+	if v, ok := d.GetOk("started_after"); ok {
+		filter.StartedAfter, _ = time.Parse(time.RFC3339, v.(string))
+	}
+	if v, ok := d.GetOk("started_before"); ok {
+		filter.StartedBefore, _ = time.Parse(time.RFC3339, v.(string))
+	}
+
+	operations, err := client.ListOperations(ctx, filter)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	// Most recent first, so operations[0] is the last matching operation.
+	// Operations without a start time go last.
+	sort.SliceStable(operations, func(i, j int) bool {
+		if operations[j].Started.IsZero() {
+			return !operations[i].Started.IsZero()
+		}
+		return operations[i].Started.After(operations[j].Started)
+	})
+
+	var operationIds []string
+	for _, op := range operations {
+		operationIds = append(operationIds, op.ID)
+	}
+
+	if err := d.Set("operations", flattenOperations(operations)); err != nil {
+		return diag.FromErr(err)
+	}
+	checksum, err := stringListChecksum(operationIds)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(checksum)
+	return nil
+}
+
+func flattenOperations(operations []ocp_client.Operation) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(operations))
+	for _, op := range operations {
+		steps := make([]map[string]interface{}, 0, len(op.Progress.StepsDetails))
+		for i, step := range op.Progress.StepsDetails {
+			steps = append(steps, map[string]interface{}{
+				"name":        step.Name,
+				"status":      operationStepStatus(op, i),
+				"error":       step.Error,
+				"started_at":  step.StartedAt,
+				"finished_at": step.FinishedAt,
+			})
+		}
+		result = append(result, map[string]interface{}{
+			"id":             op.ID,
+			"operation_type": op.OperationType,
+			"status":         op.Status,
+			"object_id":      op.PrimaryObjectID,
+			"initiator":      op.Initiator,
+			"started_at":     op.StartedAt,
+			"finished_at":    op.FinishedAt,
+			"error_message":  op.ErrorMessage,
+			"steps":          steps,
+		})
+	}
+	return result
+}
+
+// operationStepStatus returns the status reported for the step, or derives it
+// from the number of completed steps if the API doesn't report one.
+func operationStepStatus(op ocp_client.Operation, i int) string {
+	if status := op.Progress.StepsDetails[i].Status; status != "" {
+		return status
+	}
+	switch {
+	case i < op.Progress.CompletedSteps:
+		return StepStatusCompleted
+	case i > op.Progress.CompletedSteps:
+		return StepStatusPending
+	case op.Status == OperationStatusInProgress:
+		return StepStatusRunning
+	case op.Status == OperationStatusSucceeded:
+		return StepStatusCompleted
+	}
+	return op.Status
+}
@@ -0,0 +1,90 @@
+package onecloud
+
+import (
+	"strconv"
+	"strings"
+)
+
+// flavorCapabilities are derived from the OpenStack extra specs of a flavor,
+// so modules can select flavors without knowing the extra spec names.
+type flavorCapabilities struct {
+	DedicatedCpu         bool
+	Numa                 bool
+	HugePages            bool
+	LocalNvme            bool
+	Gpu                  bool
+	NetworkBandwidthMbps int
+}
+
+func getFlavorCapabilities(specs map[string]string) flavorCapabilities {
+	capabilities := flavorCapabilities{
+		DedicatedCpu: specs["hw:cpu_policy"] == "dedicated",
+	}
+	if nodes, err := strconv.Atoi(specs["hw:numa_nodes"]); err == nil && nodes > 0 {
+		capabilities.Numa = true
+	}
+	if pageSize := specs["hw:mem_page_size"]; pageSize != "" && pageSize != "small" && pageSize != "any" {
+		capabilities.HugePages = true
+	}
+	// quota:vif_outbound_average is in kilobytes per second.
+	if average, err := strconv.Atoi(specs["quota:vif_outbound_average"]); err == nil {
+		capabilities.NetworkBandwidthMbps = average * 8 / 1000
+	}
+
+	for key, value := range specs {
+		lowerKey := strings.ToLower(key)
+		switch {
+		case strings.HasPrefix(lowerKey, "pci_passthrough:alias"):
+			// Aliases also pass through NICs, NVMe drives and FPGAs.
+			if pciAliasesGpu(value) {
+				capabilities.Gpu = true
+			}
+		case strings.HasPrefix(lowerKey, "resources:vgpu"):
+			capabilities.Gpu = true
+		case strings.Contains(lowerKey, "gpu") && extraSpecEnabled(value):
+			capabilities.Gpu = true
+		case strings.Contains(lowerKey, "nvme") && extraSpecEnabled(value), strings.EqualFold(value, "nvme"):
+			capabilities.LocalNvme = true
+		}
+	}
+	return capabilities
+}
+
+// extraSpecEnabled reports whether an extra spec value turns a feature on,
+// including the "required" value of trait extra specs.
+func extraSpecEnabled(value string) bool {
+	switch strings.ToLower(value) {
+	case "true", "yes", "1", "required":
+		return true
+	}
+	return false
+}
+
+// gpuAliasVendors are the words that mark a PCI alias as a GPU anywhere in
+// its name, gpuAliasModels the GPU models matched as whole words.
+var gpuAliasVendors = []string{"gpu", "nvidia", "tesla", "quadro", "radeon", "instinct"}
+var gpuAliasModels = []string{"a2", "a10", "a16", "a30", "a40", "a100", "h100", "h200", "l4", "l40", "l40s", "t4", "v100", "mi100", "mi210", "mi250", "mi300"}
+
+// pciAliasesGpu reports whether a pci_passthrough:alias value, a list of
+// name:count pairs, passes through a GPU.
+func pciAliasesGpu(value string) bool {
+	for _, alias := range strings.Split(strings.ToLower(value), ",") {
+		name, _, _ := strings.Cut(strings.TrimSpace(alias), ":")
+		for _, vendor := range gpuAliasVendors {
+			if strings.Contains(name, vendor) {
+				return true
+			}
+		}
+		words := strings.FieldsFunc(name, func(r rune) bool {
+			return !('a' <= r && r <= 'z' || '0' <= r && r <= '9')
+		})
+		for _, word := range words {
+			for _, model := range gpuAliasModels {
+				if word == model {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package onecloud
+
+import (
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"net/netip"
+)
+
+func functionCidrOverlaps() providerFunction {
+	return providerFunction{
+		definition: &tfprotov5.Function{
+			Summary:     "Check CIDR blocks for overlaps",
+			Description: "Returns true if any two CIDR blocks in the list overlap.",
+			Parameters: []*tfprotov5.FunctionParameter{
+				{
+					Name:        "list",
+					Type:        tftypes.List{ElementType: tftypes.String},
+					Description: "List of CIDR blocks, for example `restriction_ips`.",
+				},
+			},
+			Return: &tfprotov5.FunctionReturn{Type: tftypes.Bool},
+		},
+		call: callCidrOverlaps,
+	}
+}
+
+func callCidrOverlaps(args []tftypes.Value) (tftypes.Value, *tfprotov5.FunctionError) {
+	elements, err := listElements(args[0])
+	if err != nil {
+		return tftypes.Value{}, functionArgumentError(0, "Invalid list: %s", err)
+	}
+
+	prefixes := make([]netip.Prefix, 0, len(elements))
+	for _, element := range elements {
+		var cidr string
+		if err := element.As(&cidr); err != nil {
+			return tftypes.Value{}, functionArgumentError(0, "Invalid list: %s", err)
+		}
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return tftypes.Value{}, functionArgumentError(0, "Invalid CIDR %q: %s", cidr, err)
+		}
+		prefixes = append(prefixes, prefix)
+	}
+
+	return tftypes.NewValue(tftypes.Bool, cidrOverlaps(prefixes)), nil
+}
+
+func cidrOverlaps(prefixes []netip.Prefix) bool {
+	for i := range prefixes {
+		for j := i + 1; j < len(prefixes); j++ {
+			if prefixes[i].Overlaps(prefixes[j]) {
+				return true
+			}
+		}
+	}
+	return false
+}
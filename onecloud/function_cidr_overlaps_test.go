@@ -0,0 +1,51 @@
+package onecloud
+
+import (
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"testing"
+)
+
+func TestCallCidrOverlaps(t *testing.T) {
+	cases := []struct {
+		name    string
+		cidrs   []string
+		want    bool
+		wantErr bool
+	}{
+		{name: "empty list", cidrs: []string{}, want: false},
+		{name: "single block", cidrs: []string{"10.0.0.0/8"}, want: false},
+		{name: "disjoint", cidrs: []string{"10.0.0.0/24", "10.0.1.0/24", "192.168.0.0/16"}, want: false},
+		{name: "nested", cidrs: []string{"10.0.0.0/8", "192.168.0.0/16", "10.1.0.0/16"}, want: true},
+		{name: "same host", cidrs: []string{"12.12.12.12/32", "12.12.12.12/32"}, want: true},
+		{name: "invalid CIDR", cidrs: []string{"10.0.0.0/8", "10.0.0.300/24"}, wantErr: true},
+		{name: "missing prefix length", cidrs: []string{"10.0.0.1"}, wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			elements := make([]tftypes.Value, 0, len(tc.cidrs))
+			for _, cidr := range tc.cidrs {
+				elements = append(elements, tftypes.NewValue(tftypes.String, cidr))
+			}
+			result, funcErr := callCidrOverlaps([]tftypes.Value{
+				tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, elements),
+			})
+			if tc.wantErr {
+				if funcErr == nil {
+					t.Fatalf("expected an error, got %v", result)
+				}
+				return
+			}
+			if funcErr != nil {
+				t.Fatalf("unexpected error: %s", funcErr.Text)
+			}
+			var got bool
+			if err := result.As(&got); err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("got %t, want %t", got, tc.want)
+			}
+		})
+	}
+}
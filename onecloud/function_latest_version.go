@@ -0,0 +1,103 @@
+package onecloud
+
+import (
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/terraform-providers/terraform-provider-onecloud/internal/ocp_client"
+)
+
+var clusterVersionFunctionType = tftypes.Object{
+	AttributeTypes: map[string]tftypes.Type{
+		"id":      tftypes.String,
+		"version": tftypes.String,
+	},
+	OptionalAttributes: map[string]struct{}{
+		"id": {},
+	},
+}
+
+func functionLatestVersion() providerFunction {
+	return providerFunction{
+		definition: &tfprotov5.Function{
+			Summary: "Pick the newest cluster version matching a constraint",
+			Description: "Returns the semantically newest Kubernetes version that satisfies the constraint, " +
+				"for example `~> 1.29` or `>= 1.28, < 1.30`. An empty constraint matches any version.",
+			Parameters: []*tfprotov5.FunctionParameter{
+				{
+					Name:        "versions",
+					Type:        tftypes.List{ElementType: clusterVersionFunctionType},
+					Description: "List of cluster versions, usually `data.ocp_cluster_version.<name>.versions`.",
+				},
+				{
+					Name:        "constraint",
+					Type:        tftypes.String,
+					Description: "Version constraint.",
+				},
+			},
+			Return: &tfprotov5.FunctionReturn{Type: tftypes.String},
+		},
+		call: callLatestVersion,
+	}
+}
+
+func callLatestVersion(args []tftypes.Value) (tftypes.Value, *tfprotov5.FunctionError) {
+	versions, err := functionClusterVersions(args[0])
+	if err != nil {
+		return tftypes.Value{}, functionArgumentError(0, "Invalid versions: %s", err)
+	}
+
+	var rawConstraint string
+	if err := args[1].As(&rawConstraint); err != nil {
+		return tftypes.Value{}, functionArgumentError(1, "Invalid constraint: %s", err)
+	}
+
+	filter := versionSearchFilter{}
+	if rawConstraint != "" {
+		constraint, err := version.NewConstraint(rawConstraint)
+		if err != nil {
+			return tftypes.Value{}, functionArgumentError(1, "Invalid constraint %q: %s", rawConstraint, err)
+		}
+		filter.constraint = constraint
+	}
+
+	versions = filterClusterVersion(versions, filter)
+	if len(versions) == 0 {
+		return tftypes.Value{}, &tfprotov5.FunctionError{
+			Text: "No cluster version matches the constraint " + rawConstraint,
+		}
+	}
+
+	latest := versions[0]
+	for _, v := range versions[1:] {
+		if compareVersions(v.Version, latest.Version) > 0 {
+			latest = v
+		}
+	}
+	return tftypes.NewValue(tftypes.String, latest.Version), nil
+}
+
+func functionClusterVersions(value tftypes.Value) ([]ocp_client.ClusterVersion, error) {
+	elements, err := listElements(value)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]ocp_client.ClusterVersion, 0, len(elements))
+	for _, element := range elements {
+		attributes, err := objectAttributes(element)
+		if err != nil {
+			return nil, err
+		}
+
+		var clusterVersion ocp_client.ClusterVersion
+		if clusterVersion.ID, err = stringAttribute(attributes, "id"); err != nil {
+			return nil, err
+		}
+		if clusterVersion.Version, err = stringAttribute(attributes, "version"); err != nil {
+			return nil, err
+		}
+		versions = append(versions, clusterVersion)
+	}
+	return versions, nil
+}
@@ -0,0 +1,61 @@
+package onecloud
+
+import (
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"testing"
+)
+
+func clusterVersionListValue(versions ...string) tftypes.Value {
+	elements := make([]tftypes.Value, 0, len(versions))
+	for _, v := range versions {
+		elements = append(elements, tftypes.NewValue(valueType(clusterVersionFunctionType), map[string]tftypes.Value{
+			"id":      tftypes.NewValue(tftypes.String, "id-"+v),
+			"version": tftypes.NewValue(tftypes.String, v),
+		}))
+	}
+	return tftypes.NewValue(valueType(tftypes.List{ElementType: clusterVersionFunctionType}), elements)
+}
+
+func TestCallLatestVersion(t *testing.T) {
+	versions := clusterVersionListValue("1.28.9", "1.29.10", "1.29.2", "1.30.1")
+
+	cases := []struct {
+		name       string
+		versions   tftypes.Value
+		constraint string
+		want       string
+		wantErr    bool
+	}{
+		{name: "any version", versions: versions, want: "1.30.1"},
+		{name: "semantic order", versions: versions, constraint: "~> 1.29.0", want: "1.29.10"},
+		{name: "range", versions: versions, constraint: ">= 1.28, < 1.30", want: "1.29.10"},
+		{name: "no version matching", versions: versions, constraint: ">= 2.0", wantErr: true},
+		{name: "empty list", versions: clusterVersionListValue(), wantErr: true},
+		{name: "invalid constraint", versions: versions, constraint: "latest", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, funcErr := callLatestVersion([]tftypes.Value{
+				tc.versions,
+				tftypes.NewValue(tftypes.String, tc.constraint),
+			})
+			if tc.wantErr {
+				if funcErr == nil {
+					t.Fatalf("expected an error, got %v", result)
+				}
+				return
+			}
+			if funcErr != nil {
+				t.Fatalf("unexpected error: %s", funcErr.Text)
+			}
+			var got string
+			if err := result.As(&got); err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package onecloud
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"gopkg.in/yaml.v3"
+)
+
+var kubeconfigFunctionType = tftypes.Object{
+	AttributeTypes: map[string]tftypes.Type{
+		"host":                   tftypes.String,
+		"cluster_ca_certificate": tftypes.String,
+		"client_certificate":     tftypes.String,
+		"client_key":             tftypes.String,
+	},
+}
+
+type kubeconfig struct {
+	CurrentContext string `yaml:"current-context"`
+	Clusters       []struct {
+		Name    string `yaml:"name"`
+		Cluster struct {
+			Server                   string `yaml:"server"`
+			CertificateAuthorityData string `yaml:"certificate-authority-data"`
+		} `yaml:"cluster"`
+	} `yaml:"clusters"`
+	Contexts []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Cluster string `yaml:"cluster"`
+			User    string `yaml:"user"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+	Users []struct {
+		Name string `yaml:"name"`
+		User struct {
+			ClientCertificateData string `yaml:"client-certificate-data"`
+			ClientKeyData         string `yaml:"client-key-data"`
+		} `yaml:"user"`
+	} `yaml:"users"`
+}
+
+func functionParseKubeconfig() providerFunction {
+	return providerFunction{
+		definition: &tfprotov5.Function{
+			Summary: "Parse a kubeconfig",
+			Description: "Returns the API server host, the cluster CA certificate and the client certificate and key " +
+				"of the current context of a kubeconfig. Certificates are returned PEM encoded.",
+			Parameters: []*tfprotov5.FunctionParameter{
+				{
+					Name:        "raw",
+					Type:        tftypes.String,
+					Description: "Kubeconfig content in YAML or JSON.",
+				},
+			},
+			Return: &tfprotov5.FunctionReturn{Type: kubeconfigFunctionType},
+		},
+		call: callParseKubeconfig,
+	}
+}
+
+func callParseKubeconfig(args []tftypes.Value) (tftypes.Value, *tfprotov5.FunctionError) {
+	var raw string
+	if err := args[0].As(&raw); err != nil {
+		return tftypes.Value{}, functionArgumentError(0, "Invalid kubeconfig: %s", err)
+	}
+
+	result, err := parseKubeconfig(raw)
+	if err != nil {
+		return tftypes.Value{}, functionArgumentError(0, "Invalid kubeconfig: %s", err)
+	}
+
+	attributes := make(map[string]tftypes.Value, len(result))
+	for name, value := range result {
+		attributes[name] = tftypes.NewValue(tftypes.String, value)
+	}
+	return tftypes.NewValue(kubeconfigFunctionType, attributes), nil
+}
+
+func parseKubeconfig(raw string) (map[string]string, error) {
+	var config kubeconfig
+	if err := yaml.Unmarshal([]byte(raw), &config); err != nil {
+		return nil, err
+	}
+
+	clusterName, userName := "", ""
+	for _, c := range config.Contexts {
+		if c.Name == config.CurrentContext || (config.CurrentContext == "" && len(config.Contexts) == 1) {
+			clusterName, userName = c.Context.Cluster, c.Context.User
+			break
+		}
+	}
+	if clusterName == "" {
+		return nil, fmt.Errorf("context %q not found", config.CurrentContext)
+	}
+
+	result := map[string]string{
+		"host":                   "",
+		"cluster_ca_certificate": "",
+		"client_certificate":     "",
+		"client_key":             "",
+	}
+
+	found := false
+	for _, c := range config.Clusters {
+		if c.Name != clusterName {
+			continue
+		}
+		found = true
+		ca, err := base64.StdEncoding.DecodeString(c.Cluster.CertificateAuthorityData)
+		if err != nil {
+			return nil, fmt.Errorf("can't decode certificate-authority-data, %w", err)
+		}
+		result["host"] = c.Cluster.Server
+		result["cluster_ca_certificate"] = string(ca)
+	}
+	if !found {
+		return nil, fmt.Errorf("cluster %q not found", clusterName)
+	}
+
+	for _, u := range config.Users {
+		if u.Name != userName {
+			continue
+		}
+		cert, err := base64.StdEncoding.DecodeString(u.User.ClientCertificateData)
+		if err != nil {
+			return nil, fmt.Errorf("can't decode client-certificate-data, %w", err)
+		}
+		key, err := base64.StdEncoding.DecodeString(u.User.ClientKeyData)
+		if err != nil {
+			return nil, fmt.Errorf("can't decode client-key-data, %w", err)
+		}
+		result["client_certificate"] = string(cert)
+		result["client_key"] = string(key)
+	}
+
+	if result["host"] == "" {
+		return nil, errors.New("cluster server is empty")
+	}
+	return result, nil
+}
@@ -0,0 +1,105 @@
+package onecloud
+
+import (
+	"encoding/base64"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"strings"
+	"testing"
+)
+
+func b64(s string) string {
+	return base64.StdEncoding.EncodeToString([]byte(s))
+}
+
+var testKubeconfig = `apiVersion: v1
+kind: Config
+current-context: admin@cluster
+clusters:
+- name: other
+  cluster:
+    server: https://other:6443
+    certificate-authority-data: ` + b64("other-ca") + `
+- name: cluster
+  cluster:
+    server: https://cluster:6443
+    certificate-authority-data: ` + b64("ca") + `
+contexts:
+- name: other
+  context:
+    cluster: other
+    user: other
+- name: admin@cluster
+  context:
+    cluster: cluster
+    user: admin
+users:
+- name: admin
+  user:
+    client-certificate-data: ` + b64("cert") + `
+    client-key-data: ` + b64("key") + `
+`
+
+func TestCallParseKubeconfig(t *testing.T) {
+	cases := []struct {
+		name    string
+		raw     string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "current context",
+			raw:  testKubeconfig,
+			want: map[string]string{
+				"host":                   "https://cluster:6443",
+				"cluster_ca_certificate": "ca",
+				"client_certificate":     "cert",
+				"client_key":             "key",
+			},
+		},
+		{
+			name: "json",
+			raw: `{"contexts": [{"name": "c", "context": {"cluster": "k", "user": "u"}}],
+				"clusters": [{"name": "k", "cluster": {"server": "https://k:6443", "certificate-authority-data": "` + b64("ca") + `"}}]}`,
+			want: map[string]string{
+				"host":                   "https://k:6443",
+				"cluster_ca_certificate": "ca",
+				"client_certificate":     "",
+				"client_key":             "",
+			},
+		},
+		{name: "malformed yaml", raw: "clusters: [", wantErr: true},
+		{name: "empty", raw: "", wantErr: true},
+		{name: "unknown context", raw: strings.Replace(testKubeconfig, "current-context: admin@cluster", "current-context: missing", 1), wantErr: true},
+		{name: "unknown cluster", raw: strings.Replace(testKubeconfig, "cluster: cluster", "cluster: missing", 1), wantErr: true},
+		{name: "invalid base64", raw: strings.Replace(testKubeconfig, b64("cert"), "not base64!", 1), wantErr: true},
+		{name: "empty server", raw: strings.Replace(testKubeconfig, "server: https://cluster:6443", "server: \"\"", 1), wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, funcErr := callParseKubeconfig([]tftypes.Value{tftypes.NewValue(tftypes.String, tc.raw)})
+			if tc.wantErr {
+				if funcErr == nil {
+					t.Fatalf("expected an error, got %v", result)
+				}
+				return
+			}
+			if funcErr != nil {
+				t.Fatalf("unexpected error: %s", funcErr.Text)
+			}
+			var attributes map[string]tftypes.Value
+			if err := result.As(&attributes); err != nil {
+				t.Fatal(err)
+			}
+			for name, want := range tc.want {
+				var got string
+				if err := attributes[name].As(&got); err != nil {
+					t.Fatal(err)
+				}
+				if got != want {
+					t.Errorf("%s: got %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
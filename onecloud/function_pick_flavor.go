@@ -0,0 +1,151 @@
+package onecloud
+
+import (
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/terraform-providers/terraform-provider-onecloud/internal/ocp_client"
+	"sort"
+)
+
+var flavorFunctionType = tftypes.Object{
+	AttributeTypes: map[string]tftypes.Type{
+		"id":           tftypes.String,
+		"name":         tftypes.String,
+		"vcpus":        tftypes.Number,
+		"memory_mb":    tftypes.Number,
+		"memory_gb":    tftypes.Number,
+		"root_gb":      tftypes.Number,
+		"out_of_stock": tftypes.Bool,
+	},
+	OptionalAttributes: map[string]struct{}{
+		"name":         {},
+		"memory_mb":    {},
+		"root_gb":      {},
+		"out_of_stock": {},
+	},
+}
+
+func functionPickFlavor() providerFunction {
+	return providerFunction{
+		definition: &tfprotov5.Function{
+			Summary: "Pick the smallest in-stock flavor",
+			Description: "Returns the ID of the smallest in-stock flavor with at least min_vcpus vCPU cores and " +
+				"min_memory_gb GB of RAM. Flavors are ordered by vCPU, then memory, then name.",
+			Parameters: []*tfprotov5.FunctionParameter{
+				{
+					Name:        "flavors",
+					Type:        tftypes.List{ElementType: flavorFunctionType},
+					Description: "List of flavors, usually `data.ocp_flavor.<name>.flavors`.",
+				},
+				{
+					Name:        "min_vcpus",
+					Type:        tftypes.Number,
+					Description: "Minimal number of vCPU cores.",
+				},
+				{
+					Name:        "min_memory_gb",
+					Type:        tftypes.Number,
+					Description: "Minimal amount of RAM in GB.",
+				},
+			},
+			Return: &tfprotov5.FunctionReturn{Type: tftypes.String},
+		},
+		call: callPickFlavor,
+	}
+}
+
+func callPickFlavor(args []tftypes.Value) (tftypes.Value, *tfprotov5.FunctionError) {
+	flavors, err := functionFlavors(args[0])
+	if err != nil {
+		return tftypes.Value{}, functionArgumentError(0, "Invalid flavors: %s", err)
+	}
+	minVcpus, err := numberValue(args[1])
+	if err != nil {
+		return tftypes.Value{}, functionArgumentError(1, "Invalid min_vcpus: %s", err)
+	}
+	minMemoryGb, err := numberValue(args[2])
+	if err != nil {
+		return tftypes.Value{}, functionArgumentError(2, "Invalid min_memory_gb: %s", err)
+	}
+
+	filter := flavorSearchFilter{
+		minVcpus:          int(minVcpus),
+		minMemoryGb:       minMemoryGb,
+		excludeOutOfStock: true,
+	}
+	flavor, err := recommendFlavor(flavors, filter)
+	if err != nil {
+		return tftypes.Value{}, &tfprotov5.FunctionError{
+			Text: fmt.Sprintf("No flavor found: %s", err),
+		}
+	}
+	return tftypes.NewValue(tftypes.String, flavor.ID), nil
+}
+
+// sortFlavorsBySize orders flavors from the smallest to the largest by vCPU,
+// memory, disk, name and ID.
+func sortFlavorsBySize(flavors []ocp_client.Flavor) {
+	sort.SliceStable(flavors, func(i, j int) bool {
+		if flavors[i].Vcpus != flavors[j].Vcpus {
+			return flavors[i].Vcpus < flavors[j].Vcpus
+		}
+		if flavors[i].MemoryGb != flavors[j].MemoryGb {
+			return flavors[i].MemoryGb < flavors[j].MemoryGb
+		}
+		if flavors[i].RootGb != flavors[j].RootGb {
+			return flavors[i].RootGb < flavors[j].RootGb
+		}
+		if flavors[i].Name != flavors[j].Name {
+			return flavors[i].Name < flavors[j].Name
+		}
+		return flavors[i].ID < flavors[j].ID
+	})
+}
+
+func functionFlavors(value tftypes.Value) ([]ocp_client.Flavor, error) {
+	elements, err := listElements(value)
+	if err != nil {
+		return nil, err
+	}
+
+	flavors := make([]ocp_client.Flavor, 0, len(elements))
+	for _, element := range elements {
+		attributes, err := objectAttributes(element)
+		if err != nil {
+			return nil, err
+		}
+
+		var flavor ocp_client.Flavor
+		if flavor.ID, err = stringAttribute(attributes, "id"); err != nil {
+			return nil, err
+		}
+		if flavor.Name, err = stringAttribute(attributes, "name"); err != nil {
+			return nil, err
+		}
+		vcpus, err := numberAttribute(attributes, "vcpus")
+		if err != nil {
+			return nil, err
+		}
+		memoryMb, err := numberAttribute(attributes, "memory_mb")
+		if err != nil {
+			return nil, err
+		}
+		if flavor.MemoryGb, err = numberAttribute(attributes, "memory_gb"); err != nil {
+			return nil, err
+		}
+		rootGb, err := numberAttribute(attributes, "root_gb")
+		if err != nil {
+			return nil, err
+		}
+		if flavor.OutOfStock, err = boolAttribute(attributes, "out_of_stock"); err != nil {
+			return nil, err
+		}
+		flavor.Vcpus = int(vcpus)
+		flavor.MemoryMb = int(memoryMb)
+		flavor.RootGb = int(rootGb)
+
+		flavors = append(flavors, flavor)
+	}
+	return flavors, nil
+}
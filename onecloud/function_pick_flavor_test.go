@@ -0,0 +1,72 @@
+package onecloud
+
+import (
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"testing"
+)
+
+func flavorValue(id string, vcpus int, memoryGb float64, outOfStock bool) tftypes.Value {
+	return tftypes.NewValue(valueType(flavorFunctionType), map[string]tftypes.Value{
+		"id":           tftypes.NewValue(tftypes.String, id),
+		"name":         tftypes.NewValue(tftypes.String, id),
+		"vcpus":        tftypes.NewValue(tftypes.Number, vcpus),
+		"memory_mb":    tftypes.NewValue(tftypes.Number, memoryGb*1024),
+		"memory_gb":    tftypes.NewValue(tftypes.Number, memoryGb),
+		"root_gb":      tftypes.NewValue(tftypes.Number, 20),
+		"out_of_stock": tftypes.NewValue(tftypes.Bool, outOfStock),
+	})
+}
+
+func flavorListValue(flavors ...tftypes.Value) tftypes.Value {
+	return tftypes.NewValue(valueType(tftypes.List{ElementType: flavorFunctionType}), flavors)
+}
+
+func TestCallPickFlavor(t *testing.T) {
+	flavors := flavorListValue(
+		flavorValue("large", 8, 32, false),
+		flavorValue("small", 2, 4, false),
+		flavorValue("medium", 4, 8, false),
+		flavorValue("medium-oos", 4, 4, true),
+	)
+
+	cases := []struct {
+		name        string
+		flavors     tftypes.Value
+		minVcpus    int
+		minMemoryGb float64
+		want        string
+		wantErr     bool
+	}{
+		{name: "smallest matching", flavors: flavors, minVcpus: 2, minMemoryGb: 4, want: "small"},
+		{name: "memory bound", flavors: flavors, minVcpus: 2, minMemoryGb: 6, want: "medium"},
+		{name: "out of stock skipped", flavors: flavors, minVcpus: 4, minMemoryGb: 4, want: "medium"},
+		{name: "nothing large enough", flavors: flavors, minVcpus: 16, minMemoryGb: 4, wantErr: true},
+		{name: "empty list", flavors: flavorListValue(), minVcpus: 1, minMemoryGb: 1, wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, funcErr := callPickFlavor([]tftypes.Value{
+				tc.flavors,
+				tftypes.NewValue(tftypes.Number, tc.minVcpus),
+				tftypes.NewValue(tftypes.Number, tc.minMemoryGb),
+			})
+			if tc.wantErr {
+				if funcErr == nil {
+					t.Fatalf("expected an error, got %v", result)
+				}
+				return
+			}
+			if funcErr != nil {
+				t.Fatalf("unexpected error: %s", funcErr.Text)
+			}
+			var got string
+			if err := result.As(&got); err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -2,9 +2,13 @@ package onecloud
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/terraform-providers/terraform-provider-onecloud/internal/ocp_client"
+	"strings"
 	"time"
 )
 
@@ -13,52 +17,498 @@ const OperationStatusFailed = "failed"
 const OperationStatusAborted = "aborted"
 const OperationStatusSucceeded = "succeeded"
 
-func waitForOperationSuccess(ctx context.Context, client ocp_client.Client, operationID string, timeout time.Duration) (interface{}, error) {
-	pending := []string{
-		OperationStatusInProgress,
+const StepStatusPending = "pending"
+const StepStatusRunning = "running"
+const StepStatusCompleted = "completed"
+
+// unknownOperationStatusGrace is how long an operation may report a status
+// the provider doesn't know before waiting fails.
+const unknownOperationStatusGrace = 2 * time.Minute
+
+// operationCancelTimeout limits how long an interrupted run waits for the
+// backend to abort an operation.
+const operationCancelTimeout = 2 * time.Minute
+
+// operationProgressInterval is how often a progress line is recorded while a
+// step is running, in addition to the lines recorded on step transitions.
+const operationProgressInterval = time.Minute
+
+// OperationError is returned when an operation finished as failed or aborted.
+type OperationError struct {
+	OperationID   string
+	OperationType string
+	Status        string
+	Message       string
+	Steps         []*operationStep
+}
+
+func (e *OperationError) Error() string {
+	msg := fmt.Sprintf("%s %s", e.OperationType, e.Status)
+	if step := e.failedStep(); step != nil {
+		msg = fmt.Sprintf("%s, at the step: %s", msg, step.Name)
+	}
+	if e.Message != "" {
+		msg = fmt.Sprintf("%s: %s", msg, e.Message)
+	}
+	return msg
+}
+
+// failedStep returns the first step that didn't complete.
+func (e *OperationError) failedStep() *operationStep {
+	for _, step := range e.Steps {
+		if step.Status != StepStatusCompleted {
+			return step
+		}
+	}
+	return nil
+}
+
+type operationStep struct {
+	Name     string
+	Status   string
+	Error    string
+	Started  time.Time
+	Finished time.Time
+}
+
+func (s *operationStep) duration(now time.Time) time.Duration {
+	if s.Started.IsZero() {
+		return 0
+	}
+	if s.Finished.IsZero() {
+		return now.Sub(s.Started).Round(time.Second)
+	}
+	return s.Finished.Sub(s.Started).Round(time.Second)
+}
+
+// operationProgress tracks the steps of an operation between polls. Start and
+// finish times are the ones observed by the provider, so they are accurate to
+// the poll interval.
+type operationProgress struct {
+	operationID   string
+	operationType string
+	started       time.Time
+	steps         []*operationStep
+	completed     int
+	lastReport    time.Time
+	reports       []string
+}
+
+func newOperationProgress(operationID string) *operationProgress {
+	return &operationProgress{
+		operationID: operationID,
+		started:     time.Now(),
+		completed:   -1,
+	}
+}
+
+// update records the state of the operation returned by the API and logs
+// every step that started or completed since the previous poll.
+func (p *operationProgress) update(ctx context.Context, operation map[string]interface{}) {
+	now := time.Now()
+	if operationType, ok := operation["operation_type"].(string); ok {
+		p.operationType = operationType
+	}
+	progress, ok := operation["progress"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	details, _ := progress["steps_details"].([]interface{})
+	var completed int
+	if v, ok := progress["completed_steps"].(float64); ok {
+		completed = int(v)
+	} else {
+		completed = finishedSteps(details)
+		if completed < p.completed {
+			completed = p.completed
+		}
+	}
+	if completed > len(details) {
+		completed = len(details)
+	}
+
+	for i, detail := range details {
+		stepDetail, _ := detail.(map[string]interface{})
+		if i == len(p.steps) {
+			p.steps = append(p.steps, &operationStep{Status: StepStatusPending})
+		}
+		step := p.steps[i]
+		if name, ok := stepDetail["name"].(string); ok {
+			step.Name = name
+		}
+		if stepErr, ok := stepDetail["error"].(string); ok {
+			step.Error = stepErr
+		}
+
+		switch {
+		case i < completed && step.Status != StepStatusCompleted:
+			if step.Started.IsZero() {
+				step.Started = p.stepStart(i)
+			}
+			step.Status = StepStatusCompleted
+			step.Finished = now
+			tflog.Info(ctx, "Operation step completed", p.stepFields(i, now))
+			p.report(now, fmt.Sprintf("step %d/%d %s completed in %s", i+1, len(details), step.Name, step.duration(now)))
+		case i == completed && step.Status == StepStatusPending:
+			step.Status = StepStatusRunning
+			step.Started = now
+			tflog.Info(ctx, "Operation step started", p.stepFields(i, now))
+			p.report(now, fmt.Sprintf("step %d/%d %s started", i+1, len(details), step.Name))
+		}
+	}
+	p.completed = completed
+
+	if p.completed < len(p.steps) && now.Sub(p.lastReport) >= operationProgressInterval {
+		step := p.steps[p.completed]
+		p.report(now, fmt.Sprintf("step %d/%d %s running for %s", p.completed+1, len(p.steps), step.Name, step.duration(now)))
 	}
-	target := []string{
-		OperationStatusSucceeded,
+}
+
+// finishedSteps returns the number of leading steps the API explicitly marks
+// as finished, for responses without completed_steps.
+func finishedSteps(details []interface{}) int {
+	for i, detail := range details {
+		stepDetail, _ := detail.(map[string]interface{})
+		status, _ := stepDetail["status"].(string)
+		finishedAt, _ := stepDetail["finished_at"].(string)
+		if status != StepStatusCompleted && (status != "" || finishedAt == "") {
+			return i
+		}
+	}
+	return len(details)
+}
+
+// stepStart returns the start of a step that completed before it was seen
+// running: the end of the previous step or the start of waiting.
+func (p *operationProgress) stepStart(i int) time.Time {
+	if i > 0 && !p.steps[i-1].Finished.IsZero() {
+		return p.steps[i-1].Finished
 	}
+	return p.started
+}
 
-	stateConf := &resource.StateChangeConf{
-		Pending:    pending,
-		Target:     target,
-		Refresh:    OperationStateRefresh(ctx, client, operationID),
-		Timeout:    timeout,
-		Delay:      10 * time.Second,
-		MinTimeout: 3 * time.Second,
+func (p *operationProgress) stepFields(i int, now time.Time) map[string]interface{} {
+	step := p.steps[i]
+	fields := map[string]interface{}{
+		"operation_id": p.operationID,
+		"step":         step.Name,
+		"progress":     fmt.Sprintf("%d/%d", i+1, len(p.steps)),
+		"started_at":   step.Started.Format(time.RFC3339),
+	}
+	if !step.Finished.IsZero() {
+		fields["finished_at"] = step.Finished.Format(time.RFC3339)
+		fields["duration"] = step.duration(now).String()
 	}
+	return fields
+}
+
+func (p *operationProgress) report(now time.Time, line string) {
+	p.lastReport = now
+	p.reports = append(p.reports, fmt.Sprintf("+%s: %s", now.Sub(p.started).Round(time.Second), line))
+}
+
+// failure returns the error for an operation that finished as failed or
+// aborted.
+func (p *operationProgress) failure(operation map[string]interface{}, status string) *OperationError {
+	opErr := &OperationError{
+		OperationID:   p.operationID,
+		OperationType: p.operationType,
+		Status:        status,
+		Steps:         p.steps,
+	}
+	for _, field := range []string{"error_message", "error"} {
+		if msg, ok := operation[field].(string); ok && msg != "" {
+			opErr.Message = msg
+			break
+		}
+	}
+	if step := opErr.failedStep(); step != nil {
+		step.Status = status
+		if !step.Started.IsZero() {
+			step.Finished = time.Now()
+		}
+		if opErr.Message == "" {
+			opErr.Message = step.Error
+		}
+	}
+	return opErr
+}
+
+// diagnostics returns the recorded progress as a warning.
+func (p *operationProgress) diagnostics() diag.Diagnostics {
+	if len(p.reports) == 0 {
+		return nil
+	}
+	return diag.Diagnostics{{
+		Severity: diag.Warning,
+		Summary:  fmt.Sprintf("Progress of operation %s %s", p.operationType, p.operationID),
+		Detail:   strings.Join(p.reports, "\n"),
+	}}
+}
+
+// waitForOperationSuccess waits for the operation to succeed. If the provider
+// has show_operation_progress enabled, the returned diagnostics hold a warning
+// with the progress of the operation. The error is an *OperationError if the
+// operation failed or was aborted.
+func waitForOperationSuccess(ctx context.Context, config *Config, client ocp_client.Client, operationID string, timeout time.Duration) (interface{}, diag.Diagnostics, error) {
+	progress := newOperationProgress(operationID)
 
-	result, err := stateConf.WaitForStateContext(ctx)
+	var diags diag.Diagnostics
+	result, err := pollOperation(ctx, config, client, progress, timeout)
+	if err != nil && ctx.Err() != nil && config.CancelOperationsOnInterrupt {
+		result, err = cancelOperation(ctx, config, client, progress)
+	}
+	if config.ShowOperationProgress {
+		diags = progress.diagnostics()
+	}
 	if err != nil {
-		return nil, fmt.Errorf(
-			"operation failed, operation_id: %s, err: %s",
+		var opErr *OperationError
+		if errors.As(err, &opErr) {
+			return nil, diags, opErr
+		}
+		return nil, diags, fmt.Errorf(
+			"operation failed, operation_id: %s, err: %w",
 			operationID, err)
 	}
 
-	return result, nil
+	return result, diags, nil
+}
+
+// pollOperation polls the operation until it finishes. The first poll happens
+// after the configured delay, then the interval grows by the backoff factor
+// from the minimum to the maximum poll interval. Statuses other than the known
+// ones are tolerated for unknownOperationStatusGrace.
+func pollOperation(ctx context.Context, config *Config, client ocp_client.Client, progress *operationProgress, timeout time.Duration) (map[string]interface{}, error) {
+	deadline := time.Now().Add(timeout)
+	wait := config.OperationPollDelay
+	interval := config.OperationPollMinInterval
+	var lastErr error
+	var unknownSince time.Time
+
+	for {
+		if remaining := time.Until(deadline); wait > remaining {
+			wait = remaining
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+		if !time.Now().Before(deadline) {
+			return nil, &resource.TimeoutError{LastError: lastErr, Timeout: timeout}
+		}
+
+		operation, code, err := client.GetOperation(ctx, progress.operationID)
+		switch {
+		case err != nil && code != 0:
+			return nil, err
+		case err != nil:
+			// The request didn't reach the API, try again on the next poll.
+			lastErr = err
+		default:
+			lastErr = nil
+			progress.update(ctx, operation)
+
+			status, _ := operation["status"].(string)
+			switch status {
+			case OperationStatusSucceeded:
+				return operation, nil
+			case OperationStatusFailed, OperationStatusAborted:
+				return nil, progress.failure(operation, status)
+			case OperationStatusInProgress:
+				unknownSince = time.Time{}
+			default:
+				if unknownSince.IsZero() {
+					unknownSince = time.Now()
+				} else if time.Since(unknownSince) >= unknownOperationStatusGrace {
+					return nil, fmt.Errorf("unexpected operation status %q for %s", status, time.Since(unknownSince).Round(time.Second))
+				}
+			}
+		}
+
+		wait = interval
+		interval = nextPollInterval(config, interval)
+	}
 }
 
-func OperationStateRefresh(ctx context.Context, client ocp_client.Client, operationID string) resource.StateRefreshFunc {
-	return func() (interface{}, string, error) {
-		operation, code, err := client.GetOperation(ctx, operationID)
+// pollUntil calls check with the operation poll settings until it reports
+// done or the deadline passes. pending and target name the states in the
+// timeout error.
+func pollUntil(ctx context.Context, config *Config, deadline time.Time, pending, target string, check func() (bool, error)) error {
+	timeout := time.Until(deadline)
+	wait := config.OperationPollDelay
+	interval := config.OperationPollMinInterval
+
+	for {
+		if remaining := time.Until(deadline); wait > remaining {
+			wait = remaining
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		if !time.Now().Before(deadline) {
+			return &resource.TimeoutError{
+				LastState:     pending,
+				ExpectedState: []string{target},
+				Timeout:       timeout,
+			}
+		}
+
+		done, err := check()
 		if err != nil {
-			if code == 0 {
-				return nil, OperationStatusInProgress, nil
+			return err
+		}
+		if done {
+			return nil
+		}
+
+		wait = interval
+		interval = nextPollInterval(config, interval)
+	}
+}
+
+// nextPollInterval grows a poll interval by the backoff factor, up to the
+// maximum poll interval.
+func nextPollInterval(config *Config, interval time.Duration) time.Duration {
+	interval = time.Duration(float64(interval) * config.OperationPollBackoff)
+	if interval > config.OperationPollMaxInterval {
+		return config.OperationPollMaxInterval
+	}
+	return interval
+}
+
+// cancelOperation aborts the operation after the context was cancelled and
+// waits until the backend reports it finished. An aborted operation is
+// returned as an *OperationError, so callers handle it as a failure. If the
+// operation finished before it could be aborted, the interruption is returned.
+func cancelOperation(ctx context.Context, config *Config, client ocp_client.Client, progress *operationProgress) (map[string]interface{}, error) {
+	interrupted := ctx.Err()
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), operationCancelTimeout)
+	defer cancel()
+
+	tflog.Warn(ctx, "Cancelling operation", map[string]interface{}{
+		"operation_id": progress.operationID,
+	})
+	_, err := client.CancelOperation(ctx, progress.operationID)
+	if err != nil {
+		return nil, fmt.Errorf("%w, and the operation can't be cancelled: %s", interrupted, err)
+	}
+
+	for {
+		operation, code, err := client.GetOperation(ctx, progress.operationID)
+		if err != nil && code != 0 {
+			return nil, fmt.Errorf("%w, and the cancelled operation can't be read: %s", interrupted, err)
+		}
+		if err == nil {
+			progress.update(ctx, operation)
+			status, _ := operation["status"].(string)
+			switch status {
+			case OperationStatusFailed, OperationStatusAborted:
+				return nil, progress.failure(operation, status)
+			case OperationStatusSucceeded:
+				return nil, interrupted
 			}
-			return nil, "", err
-		}
-		var createErr error
-		operationStatus := operation["status"].(string)
-		if operationStatus == OperationStatusFailed || operationStatus == OperationStatusAborted {
-			operationType := operation["operation_type"].(string)
-			progress := operation["progress"].(map[string]interface{})
-			steps := progress["steps_details"].([]interface{})
-			latestStep := steps[int(progress["completed_steps"].(float64))]
-			stepName := latestStep.(map[string]interface{})["name"].(string)
-			createErr = fmt.Errorf("%s, at the step: %s", operationType, stepName)
-		}
-		return operation, operationStatus, createErr
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w, and the operation wasn't aborted within %s", interrupted, operationCancelTimeout)
+		case <-time.After(config.OperationPollMinInterval):
+		}
+	}
+}
+
+// operationInterrupted reports whether waiting stopped because of a timeout or
+// a cancelled context rather than because the operation itself failed. An
+// operation aborted by cancel_operations_on_interrupt counts as failed.
+func operationInterrupted(ctx context.Context, err error) bool {
+	var opErr *OperationError
+	if errors.As(err, &opErr) {
+		return false
+	}
+	var timeoutErr *resource.TimeoutError
+	return ctx.Err() != nil || errors.As(err, &timeoutErr)
+}
+
+// operationDiagnostics converts an error returned by waitForOperationSuccess to
+// diagnostics. A failed operation is reported with the status of all steps and
+// the error message of the backend.
+func operationDiagnostics(err error) diag.Diagnostics {
+	var opErr *OperationError
+	if !errors.As(err, &opErr) {
+		return diag.FromErr(err)
+	}
+
+	now := time.Now()
+	lines := make([]string, 0, len(opErr.Steps)+2)
+	lines = append(lines, fmt.Sprintf("Operation %s:", opErr.OperationID))
+	for i, step := range opErr.Steps {
+		line := fmt.Sprintf("%d. %s: %s", i+1, step.Name, step.Status)
+		if d := step.duration(now); d > 0 {
+			line = fmt.Sprintf("%s (%s)", line, d)
+		}
+		if step.Error != "" {
+			line = fmt.Sprintf("%s, %s", line, step.Error)
+		}
+		lines = append(lines, line)
+	}
+	if opErr.Message != "" {
+		lines = append(lines, fmt.Sprintf("Error: %s", opErr.Message))
+	}
+
+	return diag.Diagnostics{{
+		Severity: diag.Error,
+		Summary:  fmt.Sprintf("Operation %s", opErr.Error()),
+		Detail:   strings.Join(lines, "\n"),
+	}}
+}
+
+// retryOnConflict calls request until it isn't rejected by a conflicting
+// operation in progress. If the API names the blocking operation, it is waited
+// for, otherwise the request is retried with the operation poll backoff. The
+// conflict is returned if it outlasts the timeout.
+func retryOnConflict(ctx context.Context, config *Config, client ocp_client.Client, timeout time.Duration, request func() error) error {
+	deadline := time.Now().Add(timeout)
+	interval := config.OperationPollMinInterval
+
+	for {
+		err := request()
+		var conflict *ocp_client.ConflictError
+		if !errors.As(err, &conflict) {
+			return err
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return fmt.Errorf("%w, still blocked after %s", err, timeout)
+		}
+
+		tflog.Info(ctx, "Request blocked by an operation in progress, retrying", map[string]interface{}{
+			"operation_id": conflict.OperationID,
+		})
+		if conflict.OperationID != "" {
+			// The blocking operation may fail, the request is retried either way.
+			_, waitErr := pollOperation(ctx, config, client, newOperationProgress(conflict.OperationID), remaining)
+			var opErr *OperationError
+			if waitErr != nil && !errors.As(waitErr, &opErr) {
+				return fmt.Errorf("%w, waiting for it failed: %s", err, waitErr)
+			}
+			continue
+		}
+
+		if interval > remaining {
+			interval = remaining
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(interval):
+		}
+		interval = nextPollInterval(config, interval)
 	}
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 func Provider() *schema.Provider {
@@ -21,12 +22,82 @@ func Provider() *schema.Provider {
 				DefaultFunc: schema.EnvDefaultFunc("OCP_API_TOKEN", nil),
 				Description: "Service user password",
 			},
+			"show_operation_progress": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("OCP_SHOW_OPERATION_PROGRESS", false),
+				Description: "Report the progress of long running operations as warnings",
+			},
+			"operation_poll_delay": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      2,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "Seconds to wait before the first poll of an operation",
+			},
+			"operation_poll_min_interval": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      3,
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "Initial interval between operation polls in seconds",
+			},
+			"operation_poll_max_interval": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      30,
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "Maximum interval between operation polls in seconds",
+			},
+			"operation_poll_backoff_factor": {
+				Type:         schema.TypeFloat,
+				Optional:     true,
+				Default:      1.5,
+				ValidateFunc: validation.FloatAtLeast(1),
+				Description:  "Factor the poll interval grows by after each poll",
+			},
+			"cancel_operations_on_interrupt": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("OCP_CANCEL_OPERATIONS_ON_INTERRUPT", false),
+				Description: "Abort the operations in progress when Terraform is interrupted",
+			},
+			"max_concurrent_operations": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "Maximum number of cluster and node pool changes running at once, 0 means no limit",
+			},
+			"catalog_cache_ttl": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      300,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "Seconds the flavor, version, addon and networking catalogs are reused, 0 disables the cache",
+			},
+			"cluster_cache_ttl": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      30,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "Seconds a cluster is reused by the cluster and node pool reads of a refresh, 0 disables the cache",
+			},
+			"catalog_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("OCP_CATALOG_FILE", ""),
+				Description: "Catalog snapshot exported with ocp-catalog, data sources read it instead of the API",
+			},
 		},
 		DataSourcesMap: map[string]*schema.Resource{
-			"ocp_flavor":             dataSourceFlavor(),
-			"ocp_cluster_version":    dataSourceClusterVersion(),
-			"ocp_cluster_networking": dataSourceClusterNetworking(),
-			"ocp_cluster_addons":     dataSourceClusterAddons(),
+			"ocp_flavor":                dataSourceFlavor(),
+			"ocp_flavor_recommendation": dataSourceFlavorRecommendation(),
+			"ocp_cluster_version":       dataSourceClusterVersion(),
+			"ocp_cluster_networking":    dataSourceClusterNetworking(),
+			"ocp_cluster_addons":        dataSourceClusterAddons(),
+			"ocp_operations":            dataSourceOperations(),
+			"ocp_compatibility":         dataSourceCompatibility(),
 		},
 		ResourcesMap: map[string]*schema.Resource{
 			"ocp_cluster":  resourceCluster(),
@@ -36,11 +107,11 @@ func Provider() *schema.Provider {
 	}
 }
 
-func configureProvider(_ context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
+func configureProvider(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
 	config, diagError := getConfig(d)
 	if diagError != nil {
 		return nil, diagError
 	}
 
-	return config, nil
+	return config, checkCatalogSnapshot(ctx, config)
 }
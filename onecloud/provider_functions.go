@@ -0,0 +1,204 @@
+package onecloud
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"math/big"
+	"sort"
+)
+
+// providerFunction is a pure, offline function exposed to Terraform 1.8+ as
+// provider::ocp::<name>.
+type providerFunction struct {
+	definition *tfprotov5.Function
+	call       func(args []tftypes.Value) (tftypes.Value, *tfprotov5.FunctionError)
+}
+
+func providerFunctions() map[string]providerFunction {
+	return map[string]providerFunction{
+		"pick_flavor":      functionPickFlavor(),
+		"latest_version":   functionLatestVersion(),
+		"parse_kubeconfig": functionParseKubeconfig(),
+		"cidr_overlaps":    functionCidrOverlaps(),
+	}
+}
+
+// providerServer serves the SDKv2 provider and adds the provider-defined
+// functions, which the SDK itself does not support.
+type providerServer struct {
+	*schema.GRPCProviderServer
+	functions map[string]providerFunction
+}
+
+func ProviderServer() tfprotov5.ProviderServer {
+	return &providerServer{
+		GRPCProviderServer: schema.NewGRPCProviderServer(Provider()),
+		functions:          providerFunctions(),
+	}
+}
+
+func (s *providerServer) GetMetadata(ctx context.Context, req *tfprotov5.GetMetadataRequest) (*tfprotov5.GetMetadataResponse, error) {
+	resp, err := s.GRPCProviderServer.GetMetadata(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+	for _, name := range s.functionNames() {
+		resp.Functions = append(resp.Functions, tfprotov5.FunctionMetadata{Name: name})
+	}
+	return resp, nil
+}
+
+func (s *providerServer) GetProviderSchema(ctx context.Context, req *tfprotov5.GetProviderSchemaRequest) (*tfprotov5.GetProviderSchemaResponse, error) {
+	resp, err := s.GRPCProviderServer.GetProviderSchema(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+	resp.Functions = s.functionDefinitions()
+	return resp, nil
+}
+
+func (s *providerServer) GetFunctions(_ context.Context, _ *tfprotov5.GetFunctionsRequest) (*tfprotov5.GetFunctionsResponse, error) {
+	return &tfprotov5.GetFunctionsResponse{
+		Functions: s.functionDefinitions(),
+	}, nil
+}
+
+func (s *providerServer) CallFunction(_ context.Context, req *tfprotov5.CallFunctionRequest) (*tfprotov5.CallFunctionResponse, error) {
+	function, ok := s.functions[req.Name]
+	if !ok {
+		return &tfprotov5.CallFunctionResponse{
+			Error: &tfprotov5.FunctionError{Text: fmt.Sprintf("Function %s does not exist", req.Name)},
+		}, nil
+	}
+
+	params := function.definition.Parameters
+	if len(req.Arguments) != len(params) {
+		return &tfprotov5.CallFunctionResponse{
+			Error: &tfprotov5.FunctionError{
+				Text: fmt.Sprintf("%s expects %d arguments, got %d", req.Name, len(params), len(req.Arguments)),
+			},
+		}, nil
+	}
+
+	args := make([]tftypes.Value, len(params))
+	for i, param := range params {
+		arg, err := req.Arguments[i].Unmarshal(valueType(param.Type))
+		if err != nil {
+			return &tfprotov5.CallFunctionResponse{
+				Error: functionArgumentError(i, "Can't decode argument %s: %s", param.Name, err),
+			}, nil
+		}
+		args[i] = arg
+	}
+
+	result, funcErr := function.call(args)
+	if funcErr != nil {
+		return &tfprotov5.CallFunctionResponse{Error: funcErr}, nil
+	}
+
+	value, err := tfprotov5.NewDynamicValue(function.definition.Return.Type, result)
+	if err != nil {
+		return &tfprotov5.CallFunctionResponse{
+			Error: &tfprotov5.FunctionError{Text: fmt.Sprintf("Can't encode result of %s: %s", req.Name, err)},
+		}, nil
+	}
+	return &tfprotov5.CallFunctionResponse{Result: &value}, nil
+}
+
+func (s *providerServer) functionNames() []string {
+	names := make([]string, 0, len(s.functions))
+	for name := range s.functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *providerServer) functionDefinitions() map[string]*tfprotov5.Function {
+	definitions := make(map[string]*tfprotov5.Function, len(s.functions))
+	for name, function := range s.functions {
+		definitions[name] = function.definition
+	}
+	return definitions
+}
+
+func functionArgumentError(argument int, format string, a ...interface{}) *tfprotov5.FunctionError {
+	position := int64(argument)
+	return &tfprotov5.FunctionError{
+		Text:             fmt.Sprintf(format, a...),
+		FunctionArgument: &position,
+	}
+}
+
+// valueType strips optional attributes from a parameter type. They are only
+// valid in type constraints, the arguments always carry concrete objects.
+func valueType(t tftypes.Type) tftypes.Type {
+	switch typ := t.(type) {
+	case tftypes.List:
+		return tftypes.List{ElementType: valueType(typ.ElementType)}
+	case tftypes.Object:
+		attributeTypes := make(map[string]tftypes.Type, len(typ.AttributeTypes))
+		for name, attributeType := range typ.AttributeTypes {
+			attributeTypes[name] = valueType(attributeType)
+		}
+		return tftypes.Object{AttributeTypes: attributeTypes}
+	}
+	return t
+}
+
+// objectAttributes returns the attributes of a known, non-null object value.
+func objectAttributes(value tftypes.Value) (map[string]tftypes.Value, error) {
+	attributes := make(map[string]tftypes.Value)
+	if err := value.As(&attributes); err != nil {
+		return nil, err
+	}
+	return attributes, nil
+}
+
+func listElements(value tftypes.Value) ([]tftypes.Value, error) {
+	var elements []tftypes.Value
+	if err := value.As(&elements); err != nil {
+		return nil, err
+	}
+	return elements, nil
+}
+
+func stringAttribute(attributes map[string]tftypes.Value, name string) (string, error) {
+	var result string
+	value, ok := attributes[name]
+	if !ok || value.IsNull() {
+		return result, nil
+	}
+	err := value.As(&result)
+	return result, err
+}
+
+func numberAttribute(attributes map[string]tftypes.Value, name string) (float64, error) {
+	value, ok := attributes[name]
+	if !ok || value.IsNull() {
+		return 0, nil
+	}
+	return numberValue(value)
+}
+
+func boolAttribute(attributes map[string]tftypes.Value, name string) (bool, error) {
+	var result bool
+	value, ok := attributes[name]
+	if !ok || value.IsNull() {
+		return result, nil
+	}
+	err := value.As(&result)
+	return result, err
+}
+
+func numberValue(value tftypes.Value) (float64, error) {
+	number := new(big.Float)
+	if err := value.As(&number); err != nil {
+		return 0, err
+	}
+	result, _ := number.Float64()
+	return result, nil
+}
@@ -2,8 +2,11 @@ package onecloud
 
 import (
 	"context"
+	"fmt"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/terraform-providers/terraform-provider-onecloud/internal/ocp_client"
 	"reflect"
 	"strings"
 	"time"
@@ -15,9 +18,10 @@ func resourceCluster() *schema.Resource {
 		ReadContext:   resourceOCPClusterRead,
 		UpdateContext: resourceOCPClusterUpdate,
 		DeleteContext: resourceOCPClusterDelete,
+		CustomizeDiff: resourceOCPClusterCustomizeDiff,
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(60 * time.Minute),
-			Update: schema.DefaultTimeout(1 * time.Minute),
+			Update: schema.DefaultTimeout(120 * time.Minute),
 			Delete: schema.DefaultTimeout(2 * time.Minute),
 		},
 		Schema: map[string]*schema.Schema{
@@ -37,12 +41,11 @@ func resourceCluster() *schema.Resource {
 			"master_flavor_id": {
 				Type:     schema.TypeString,
 				Required: true,
-				ForceNew: true,
 			},
 			"master_count": {
-				Type:     schema.TypeInt,
-				Required: true,
-				ForceNew: true,
+				Type:         schema.TypeInt,
+				Required:     true,
+				ValidateFunc: validateMasterCount,
 			},
 			"image": {
 				Type:     schema.TypeString,
@@ -115,6 +118,20 @@ func resourceCluster() *schema.Resource {
 							Type:     schema.TypeString,
 							Computed: true,
 						},
+						"image": {
+							Type:     schema.TypeString,
+							Optional: true,
+							Computed: true,
+						},
+						"kubernetes_version": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"current_kubernetes_version": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"upgrade_settings": upgradeSettingsSchema(),
 						"node_count": {
 							Type:     schema.TypeInt,
 							Required: true,
@@ -233,6 +250,26 @@ func resourceCluster() *schema.Resource {
 				Type:     schema.TypeString,
 				Computed: true,
 			},
+			"pending_operation_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"on_create_failure": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ValidateFunc: validation.StringInSlice([]string{
+					CreateFailureDelete, CreateFailureKeep, CreateFailureTaint,
+				}, false),
+			},
+			"failed_operation_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+				ForceNew: true,
+			},
+			"upgrade_available": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
 			"created_at": {
 				Type:     schema.TypeString,
 				Computed: true,
@@ -250,17 +287,33 @@ func resourceOCPClusterRead(ctx context.Context, d *schema.ResourceData, meta in
 	if err != nil {
 		return diag.FromErr(err)
 	}
-	cluster, err := client.GetCluster(ctx, d.Id())
+	if operationId := d.Get("pending_operation_id").(string); operationId != "" {
+		return readPendingCluster(ctx, d, meta.(*Config), client, operationId)
+	}
+	if !resolveFailedClusterId(ctx, d, client) {
+		return diag.Diagnostics{{
+			Severity: diag.Warning,
+			Summary:  "Failed cluster is unknown",
+			Detail: fmt.Sprintf("Operation %s failed to create the cluster and doesn't report its ID yet. "+
+				"The next apply replaces it.", d.Id()),
+		}}
+	}
+
+	cluster, err := getCachedCluster(ctx, meta, d.Id())
 	if err != nil {
 		return diag.FromErr(err)
 	}
+	if cluster == nil {
+		d.SetId("")
+		return nil
+	}
 
 	fetchErr := fetchClusterState(cluster, d)
 	if fetchErr != nil {
 		return fetchErr
 	}
 
-	return nil
+	return setClusterVersionLifecycle(ctx, d, client)
 }
 
 func resourceOCPClusterCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -268,34 +321,229 @@ func resourceOCPClusterCreate(ctx context.Context, d *schema.ResourceData, meta
 	if err != nil {
 		return diag.FromErr(err)
 	}
+	config := meta.(*Config)
+	release, err := config.startMutation(ctx, "")
+	if err != nil {
+		return diag.FromErr(err)
+	}
 
 	clusterData := GetClusterCreateOptions(d)
 
 	resp, err := client.CreateCluster(ctx, clusterData)
 	if err != nil {
+		release()
 		return diag.FromErr(err)
 	}
 	operationId := resp["operation_id"].(string)
 
-	result, waitErr := waitForOperationSuccess(ctx, *client, operationId, d.Timeout(schema.TimeoutCreate))
+	// Record the cluster before waiting, so an interrupted apply resumes the
+	// operation on the next run instead of creating a second cluster.
+	d.SetId(pendingClusterId(ctx, client, operationId))
+	err = d.Set("pending_operation_id", operationId)
+	if err != nil {
+		release()
+		return diag.FromErr(err)
+	}
+
+	diags, createErr := waitForClusterCreate(ctx, d, config, client, d.Timeout(schema.TimeoutCreate))
+	// The cleanup of a failed cluster takes the cluster lock and a slot of its
+	// own.
+	release()
+	if createErr != nil {
+		return append(diags, handleClusterCreateFailure(ctx, d, config, client, createErr, false)...)
+	}
+	return diags
+}
+
+// readPendingCluster reads a cluster whose creation is still recorded as
+// pending without waiting for it, so plan and refresh aren't blocked. A
+// finished operation is resolved, a running one is left to the next apply,
+// which waits for it in Update.
+func readPendingCluster(ctx context.Context, d *schema.ResourceData, config *Config, client *ocp_client.Client, operationId string) diag.Diagnostics {
+	operation, _, err := client.GetOperation(ctx, operationId)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	status, _ := operation["status"].(string)
+	if status == OperationStatusSucceeded || status == OperationStatusFailed || status == OperationStatusAborted {
+		diags, createErr := waitForClusterCreate(ctx, d, config, client, d.Timeout(schema.TimeoutCreate))
+		if createErr != nil {
+			// Only recorded, the next apply replaces the cluster.
+			return append(diags, handleClusterCreateFailure(ctx, d, config, client, createErr, true)...)
+		}
+		return diags
+	}
+
+	if clusterId, ok := operation["primary_object_id"].(string); ok && clusterId != "" {
+		d.SetId(clusterId)
+		cluster, err := getCachedCluster(ctx, config, clusterId)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		if cluster != nil {
+			if fetchErr := fetchClusterState(cluster, d); fetchErr != nil {
+				return fetchErr
+			}
+		}
+	}
+	return diag.Diagnostics{{
+		Severity: diag.Warning,
+		Summary:  "Cluster creation is still in progress",
+		Detail:   fmt.Sprintf("Operation %s hasn't finished yet. The next apply waits for it.", operationId),
+	}}
+}
+
+// waitForClusterCreate waits for the create operation recorded in
+// pending_operation_id. When waiting is interrupted the partial state is kept
+// and a warning is returned, the next apply resumes waiting. If the operation
+// failed or was aborted, its error is returned for handleClusterCreateFailure.
+func waitForClusterCreate(ctx context.Context, d *schema.ResourceData, config *Config, client *ocp_client.Client, timeout time.Duration) (diag.Diagnostics, error) {
+	operationId := d.Get("pending_operation_id").(string)
+
+	result, diags, waitErr := waitForOperationSuccess(ctx, config, *client, operationId, timeout)
 	if waitErr != nil {
-		return diag.FromErr(waitErr)
+		if operationInterrupted(ctx, waitErr) {
+			return append(diags, diag.Diagnostic{
+				Severity: diag.Warning,
+				Summary:  "Cluster creation is still in progress",
+				Detail: fmt.Sprintf("Waiting for operation %s was interrupted: %s. "+
+					"The cluster is kept in state and the next apply resumes waiting for it.",
+					operationId, waitErr),
+			}), nil
+		}
+		return diags, waitErr
 	}
 
 	clusterId := result.(map[string]interface{})["primary_object_id"].(string)
 	d.SetId(clusterId)
+	err := d.Set("pending_operation_id", "")
+	if err != nil {
+		return append(diags, diag.FromErr(err)...), nil
+	}
 
 	cluster, err := client.GetCluster(ctx, clusterId)
+	if err != nil {
+		return append(diags, diag.FromErr(err)...), nil
+	}
+
+	return append(diags, fetchClusterState(cluster, d)...), nil
+}
+
+// handleClusterCreateFailure applies on_create_failure to a cluster whose
+// create operation failed or was aborted. The cluster is recorded in state
+// with failed_operation_id and, under the delete policy, deleted unless the
+// failure was found by Read: plan must not change anything, the replacement
+// forced by CustomizeDiff deletes it instead. The ID is only cleared once the
+// cluster is gone.
+//
+// An error taints a cluster recorded by Create, so keep and Read report a
+// warning.
+func handleClusterCreateFailure(ctx context.Context, d *schema.ResourceData, config *Config, client *ocp_client.Client, createErr error, fromRead bool) diag.Diagnostics {
+	if ctx.Err() != nil {
+		// The operation was aborted on interrupt, apply the policy anyway.
+		ctx = context.WithoutCancel(ctx)
+	}
+	operationId := d.Get("pending_operation_id").(string)
+	clusterId := d.Id()
+	if clusterId == operationId {
+		clusterId = pendingClusterId(ctx, client, operationId)
+	}
+	policy := createFailurePolicy(d.Get("on_create_failure").(string))
+
+	severity := diag.Error
+	if fromRead || policy == CreateFailureKeep {
+		severity = diag.Warning
+	}
+	failure := operationDiagnostics(createErr)[0]
+
+	d.SetId(clusterId)
+	err := d.Set("pending_operation_id", "")
 	if err != nil {
 		return diag.FromErr(err)
 	}
+	err = d.Set("failed_operation_id", operationId)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if clusterId != operationId {
+		cluster, err := client.GetCluster(ctx, clusterId)
+		if err == nil && cluster != nil {
+			if fetchErr := fetchClusterState(cluster, d); fetchErr != nil {
+				return append(fetchErr, diag.Errorf("cluster creation failed: %s", createErr)...)
+			}
+		}
+	}
 
-	fetchErr := fetchClusterState(cluster, d)
-	if fetchErr != nil {
-		return fetchErr
+	var detail string
+	switch {
+	case policy == CreateFailureKeep:
+		detail = fmt.Sprintf("The partial cluster %s is recorded in state and kept until it is destroyed or on_create_failure is set to taint.", clusterId)
+	case policy == CreateFailureDelete && !fromRead && clusterId != operationId:
+		deleteErr := deleteFailedCluster(ctx, d, config, client, clusterId)
+		if !deleteErr.HasError() {
+			d.SetId("")
+			detail = fmt.Sprintf("The partial cluster %s was deleted.", clusterId)
+		} else {
+			detail = fmt.Sprintf("The partial cluster %s can't be deleted: %s. It is recorded in state and replaced on the next apply.",
+				clusterId, deleteErr[len(deleteErr)-1].Summary)
+		}
+	case clusterId == operationId:
+		detail = fmt.Sprintf("The operation didn't report a cluster ID yet. It is recorded in state as %s and replaced on the next apply.", operationId)
+	default:
+		detail = fmt.Sprintf("The partial cluster %s is recorded in state and replaced on the next apply.", clusterId)
 	}
 
-	return nil
+	return diag.Diagnostics{{
+		Severity: severity,
+		Summary:  fmt.Sprintf("Cluster creation failed: %s", createErr),
+		Detail:   fmt.Sprintf("%s\n\n%s", failure.Detail, detail),
+	}}
+}
+
+// deleteFailedCluster deletes a cluster whose creation failed, taking the
+// cluster lock and an operation slot like every other mutation.
+func deleteFailedCluster(ctx context.Context, d *schema.ResourceData, config *Config, client *ocp_client.Client, clusterId string) diag.Diagnostics {
+	release, err := config.startMutation(ctx, clusterId)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	defer release()
+	return deleteCluster(ctx, config, client, clusterId, d.Timeout(schema.TimeoutDelete))
+}
+
+// resolveFailedClusterId replaces an operation ID recorded as the cluster ID
+// with the ID of the cluster, once the operation reports it. It returns false
+// while the cluster is unknown.
+func resolveFailedClusterId(ctx context.Context, d *schema.ResourceData, client *ocp_client.Client) bool {
+	operationId := d.Get("failed_operation_id").(string)
+	if operationId == "" || d.Id() != operationId {
+		return true
+	}
+	d.SetId(pendingClusterId(ctx, client, operationId))
+	return d.Id() != operationId
+}
+
+// createFailurePolicy returns the on_create_failure policy, an empty value
+// means taint.
+func createFailurePolicy(policy string) string {
+	if policy == "" {
+		return CreateFailureTaint
+	}
+	return policy
+}
+
+// pendingClusterId returns the ID of the cluster being created by the
+// operation, or the operation ID if the backend doesn't report it yet.
+func pendingClusterId(ctx context.Context, client *ocp_client.Client, operationId string) string {
+	operation, _, err := client.GetOperation(ctx, operationId)
+	if err != nil {
+		return operationId
+	}
+	if clusterId, ok := operation["primary_object_id"].(string); ok && clusterId != "" {
+		return clusterId
+	}
+	return operationId
 }
 
 func resourceOCPClusterUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -303,6 +551,31 @@ func resourceOCPClusterUpdate(ctx context.Context, d *schema.ResourceData, meta
 	if err != nil {
 		return diag.FromErr(err)
 	}
+	config := meta.(*Config)
+
+	var diags diag.Diagnostics
+	if operationId := d.Get("pending_operation_id").(string); operationId != "" {
+		// An interrupted create, planned by CustomizeDiff. Waiting isn't a
+		// mutation, the cleanup of a failed cluster takes the lock itself.
+		var createErr error
+		diags, createErr = waitForClusterCreate(ctx, d, config, client, d.Timeout(schema.TimeoutUpdate))
+		if createErr != nil {
+			return append(diags, handleClusterCreateFailure(ctx, d, config, client, createErr, false)...)
+		}
+		if diags.HasError() {
+			return diags
+		}
+		if d.Get("pending_operation_id").(string) != "" {
+			return append(diags, diag.Errorf("cluster creation is still in progress, operation %s", operationId)...)
+		}
+	}
+
+	release, err := config.startMutation(ctx, d.Id())
+	if err != nil {
+		return append(diags, diag.FromErr(err)...)
+	}
+	defer release()
+	deadline := time.Now().Add(d.Timeout(schema.TimeoutUpdate))
 
 	var clusterUpdate bool = false
 	clusterUpdateData := make(map[string]interface{})
@@ -315,16 +588,65 @@ func resourceOCPClusterUpdate(ctx context.Context, d *schema.ResourceData, meta
 		clusterUpdateData["restriction_api"] = d.Get("restriction_api")
 		clusterUpdateData["restriction_ips"] = d.Get("restriction_ips")
 	}
+	if d.HasChange("master_count") {
+		clusterUpdate = true
+		clusterUpdateData["master_count"] = d.Get("master_count")
+	}
 	if clusterUpdate {
-		resp, err := client.UpdateCluster(ctx, d.Id(), clusterUpdateData)
+		var resp map[string]interface{}
+		err := retryOnConflict(ctx, config, *client, d.Timeout(schema.TimeoutUpdate), func() (err error) {
+			resp, err = client.UpdateCluster(ctx, d.Id(), clusterUpdateData)
+			return err
+		})
 		if err != nil {
 			return diag.FromErr(err)
 		}
-		for field, value := range resp {
-			err := d.Set(field, value)
+		if operationId, ok := resp["operation_id"].(string); ok {
+			_, progress, waitErr := waitForOperationSuccess(ctx, config, *client, operationId, d.Timeout(schema.TimeoutUpdate))
+			diags = append(diags, progress...)
+			if waitErr != nil {
+				return append(diags, operationDiagnostics(waitErr)...)
+			}
+
+			cluster, err := client.GetCluster(ctx, d.Id())
 			if err != nil {
 				return diag.FromErr(err)
 			}
+			fetchErr := fetchClusterState(cluster, d)
+			if fetchErr != nil {
+				return fetchErr
+			}
+		} else {
+			for field, value := range resp {
+				err := d.Set(field, value)
+				if err != nil {
+					return diag.FromErr(err)
+				}
+			}
+		}
+	}
+
+	if d.HasChange("master_flavor_id") {
+		// Resized after scaling, so nodes added by master_count are replaced
+		// too.
+		rollDiags := rollControlPlane(ctx, config, client, d.Id(), d.Get("master_flavor_id").(string), deadline)
+		diags = append(diags, rollDiags...)
+		if rollDiags.HasError() {
+			// Keep the old flavor in state, the next apply resumes the roll.
+			oldFlavorId, _ := d.GetChange("master_flavor_id")
+			if err := d.Set("master_flavor_id", oldFlavorId); err != nil {
+				return append(diags, diag.FromErr(err)...)
+			}
+			return diags
+		}
+
+		cluster, err := client.GetCluster(ctx, d.Id())
+		if err != nil {
+			return append(diags, diag.FromErr(err)...)
+		}
+		fetchErr := fetchClusterState(cluster, d)
+		if fetchErr != nil {
+			return append(diags, fetchErr...)
 		}
 	}
 
@@ -345,14 +667,20 @@ func resourceOCPClusterUpdate(ctx context.Context, d *schema.ResourceData, meta
 
 			if !reflect.DeepEqual(oldNodePool["node_count"], newNodePool["node_count"]) ||
 				!reflect.DeepEqual(oldNodePool["autoscale"], newNodePool["autoscale"]) ||
-				!reflect.DeepEqual(oldNodePool["max_count"], newNodePool["max_count"]) {
+				!reflect.DeepEqual(oldNodePool["max_count"], newNodePool["max_count"]) ||
+				!reflect.DeepEqual(oldNodePool["upgrade_settings"], newNodePool["upgrade_settings"]) {
 
 				data := map[string]interface{}{
-					"count":     newNodePool["node_count"],
-					"autoscale": newNodePool["autoscale"],
-					"max_count": newNodePool["max_count"],
+					"count":            newNodePool["node_count"],
+					"autoscale":        newNodePool["autoscale"],
+					"max_count":        newNodePool["max_count"],
+					"upgrade_settings": getUpgradeSettings(newNodePool["upgrade_settings"].([]interface{})),
 				}
-				resp, err := client.UpdateNodePool(ctx, oldNodePool["id"].(string), data)
+				var resp map[string]interface{}
+				err := retryOnConflict(ctx, config, *client, d.Timeout(schema.TimeoutUpdate), func() (err error) {
+					resp, err = client.UpdateNodePool(ctx, oldNodePool["id"].(string), data)
+					return err
+				})
 				if err != nil {
 					return diag.FromErr(err)
 				}
@@ -360,15 +688,56 @@ func resourceOCPClusterUpdate(ctx context.Context, d *schema.ResourceData, meta
 				newNodePool["node_count"] = resp["count"]
 				newNodePool["autoscale"] = resp["autoscale"]
 				newNodePool["max_count"] = resp["max_count"]
+				if settings, ok := resp["upgrade_settings"].(map[string]interface{}); ok {
+					newNodePool["upgrade_settings"] = flattenUpgradeSettings(settings)
+				}
 
 				err = d.Set("node_pool", []map[string]interface{}{newNodePool})
 				if err != nil {
 					return diag.FromErr(err)
 				}
 			}
+
+			imageChanged := !reflect.DeepEqual(oldNodePool["image"], newNodePool["image"])
+			versionChanged := !reflect.DeepEqual(oldNodePool["kubernetes_version"], newNodePool["kubernetes_version"])
+			if imageChanged || versionChanged {
+				data := map[string]interface{}{
+					"upgrade_settings": getUpgradeSettings(newNodePool["upgrade_settings"].([]interface{})),
+				}
+				if imageChanged {
+					data["image"] = newNodePool["image"]
+				}
+				if versionChanged {
+					data["kubernetes_version"] = nodePoolVersionPin(newNodePool["kubernetes_version"].(string))
+				}
+				var resp map[string]interface{}
+				err := retryOnConflict(ctx, config, *client, d.Timeout(schema.TimeoutUpdate), func() (err error) {
+					resp, err = client.UpdateNodePool(ctx, oldNodePool["id"].(string), data)
+					return err
+				})
+				if err != nil {
+					return diag.FromErr(err)
+				}
+				if operationId, ok := resp["operation_id"].(string); ok {
+					_, progress, waitErr := waitForOperationSuccess(ctx, config, *client, operationId, d.Timeout(schema.TimeoutUpdate))
+					diags = append(diags, progress...)
+					if waitErr != nil {
+						return append(diags, operationDiagnostics(waitErr)...)
+					}
+				}
+
+				cluster, err := client.GetCluster(ctx, d.Id())
+				if err != nil {
+					return diag.FromErr(err)
+				}
+				fetchErr := fetchClusterState(cluster, d)
+				if fetchErr != nil {
+					return fetchErr
+				}
+			}
 		}
 	}
-	return nil
+	return diags
 }
 
 func resourceOCPClusterDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -376,36 +745,154 @@ func resourceOCPClusterDelete(ctx context.Context, d *schema.ResourceData, meta
 	if err != nil {
 		return diag.FromErr(err)
 	}
+	config := meta.(*Config)
+	if !resolveFailedClusterId(ctx, d, client) {
+		return diag.Diagnostics{{
+			Severity: diag.Warning,
+			Summary:  "Failed cluster is unknown",
+			Detail: fmt.Sprintf("Operation %s failed to create the cluster and doesn't report its ID, there is nothing to delete. "+
+				"Check the operation if it created a cluster.", d.Id()),
+		}}
+	}
+	release, err := config.startMutation(ctx, d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	defer release()
 
-	resp, err := client.DeleteCluster(ctx, d.Id())
+	return deleteCluster(ctx, config, client, d.Id(), d.Timeout(schema.TimeoutDelete))
+}
+
+func deleteCluster(ctx context.Context, config *Config, client *ocp_client.Client, clusterId string, timeout time.Duration) diag.Diagnostics {
+	var resp map[string]interface{}
+	err := retryOnConflict(ctx, config, *client, timeout, func() (err error) {
+		resp, err = client.DeleteCluster(ctx, clusterId)
+		return err
+	})
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
 	operationId := resp["operation_id"].(string)
-	_, waitErr := waitForOperationSuccess(ctx, *client, operationId, d.Timeout(schema.TimeoutDelete))
+	_, diags, waitErr := waitForOperationSuccess(ctx, config, *client, operationId, timeout)
 	if waitErr != nil {
-		return diag.FromErr(waitErr)
+		return append(diags, operationDiagnostics(waitErr)...)
+	}
+
+	return diags
+}
+
+func resourceOCPClusterCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() != "" && d.Get("pending_operation_id").(string) != "" {
+		// Creation was interrupted, the apply waits for it in Update.
+		if err := d.SetNewComputed("pending_operation_id"); err != nil {
+			return err
+		}
+	}
+	if d.Id() != "" && d.Get("failed_operation_id").(string) != "" &&
+		createFailurePolicy(d.Get("on_create_failure").(string)) != CreateFailureKeep {
+		// A failed create recorded by Read or Update isn't tainted,
+		// failed_operation_id forces the replacement instead. Under the delete
+		// policy the replacement deletes the cluster.
+		if err := d.SetNewComputed("failed_operation_id"); err != nil {
+			return err
+		}
 	}
 
+	if err := validateUpgradeSettings(d, "node_pool.0.upgrade_settings.0"); err != nil {
+		return err
+	}
+
+	client, err := getOCPClient(meta)
+	if err != nil {
+		return err
+	}
+	if err := validateClusterCompatibility(ctx, d, client); err != nil {
+		return err
+	}
+
+	if !d.NewValueKnown("cluster_version") {
+		return nil
+	}
+	clusterVersion := d.Get("cluster_version").(string)
+	if d.Id() != "" && d.HasChange("cluster_version") {
+		inlinePoolId, _ := d.Get("node_pool.0.id").(string)
+		if err := validateNodePoolsVersionSkew(ctx, meta, d.Id(), inlinePoolId, clusterVersion); err != nil {
+			return err
+		}
+	}
+
+	if !d.NewValueKnown("node_pool.0.kubernetes_version") {
+		return nil
+	}
+	poolVersion := d.Get("node_pool.0.kubernetes_version").(string)
+	if poolVersion == "" {
+		return nil
+	}
+	return validateVersionSkew(clusterVersion, poolVersion)
+}
+
+// validateNodePoolsVersionSkew checks a control plane upgrade against the
+// node pools the cluster already has, including the ones managed by
+// ocp_nodepool resources. The inline node pool is skipped, its planned version
+// is checked instead.
+func validateNodePoolsVersionSkew(ctx context.Context, meta interface{}, clusterId, inlinePoolId, clusterVersion string) error {
+	cluster, err := getCachedCluster(ctx, meta, clusterId)
+	if err != nil {
+		return err
+	}
+	nodePools, _ := cluster["node_pools"].([]interface{})
+	for _, np := range nodePools {
+		nodePool, ok := np.(map[string]interface{})
+		if !ok || (inlinePoolId != "" && nodePool["id"] == inlinePoolId) {
+			continue
+		}
+		poolVersion := nodePoolVersion(nodePool)
+		if poolVersion == "" {
+			continue
+		}
+		if err := validateVersionSkew(clusterVersion, poolVersion); err != nil {
+			return fmt.Errorf("node pool %v: %w", nodePool["name"], err)
+		}
+	}
 	return nil
 }
 
+const CreateFailureDelete = "delete"
+const CreateFailureKeep = "keep"
+const CreateFailureTaint = "taint"
+
+func validateMasterCount(v interface{}, k string) (warnings []string, errs []error) {
+	count := v.(int)
+	if count < 1 || count%2 == 0 {
+		errs = append(errs, fmt.Errorf("%s must be an odd number of control plane nodes (1, 3, 5, ...), got: %d", k, count))
+	}
+	return warnings, errs
+}
+
 func fetchClusterState(cluster map[string]interface{}, d *schema.ResourceData) diag.Diagnostics {
 	nodePoolsMap := make(map[string]map[string]interface{})
-	clusterNodePools := cluster["node_pools"].([]interface{})
+	clusterNodePools, _ := cluster["node_pools"].([]interface{})
 	nodePools := d.Get("node_pool").([]interface{})[0].(map[string]interface{})
 
 	for _, np := range clusterNodePools {
 		nodePoolsMap[np.(map[string]interface{})["name"].(string)] = np.(map[string]interface{})
 	}
 
-	mappedNp := nodePoolsMap[nodePools["name"].(string)]
-	nodePools["id"] = mappedNp["id"].(string)
-	nodePools["flavor"] = mappedNp["flavor"].(string)
-	nodePools["is_default"] = mappedNp["is_default"].(bool)
-	nodePools["status"] = mappedNp["status"].(string)
-	nodePools["nodes"] = mappedNp["nodes"].([]interface{})
+	// A cluster that failed to create may have no node pool yet.
+	if mappedNp, ok := nodePoolsMap[nodePools["name"].(string)]; ok {
+		nodePools["id"] = mappedNp["id"].(string)
+		nodePools["flavor"] = mappedNp["flavor"].(string)
+		nodePools["image"] = mappedNp["image"]
+		nodePools["kubernetes_version"], _ = mappedNp["kubernetes_version"].(string)
+		nodePools["current_kubernetes_version"] = nodePoolVersion(mappedNp)
+		if settings, ok := mappedNp["upgrade_settings"].(map[string]interface{}); ok {
+			nodePools["upgrade_settings"] = flattenUpgradeSettings(settings)
+		}
+		nodePools["is_default"] = mappedNp["is_default"].(bool)
+		nodePools["status"] = mappedNp["status"].(string)
+		nodePools["nodes"] = mappedNp["nodes"].([]interface{})
+	}
 
 	err := d.Set("cluster_name", cluster["cluster_name"])
 	if err != nil {
@@ -415,6 +902,22 @@ func fetchClusterState(cluster map[string]interface{}, d *schema.ResourceData) d
 	if err != nil {
 		return diag.FromErr(err)
 	}
+	// Read back the control plane size and flavor, so a failed or out-of-band
+	// scale or resize shows up as drift.
+	if masterCount, ok := cluster["master_count"].(float64); ok {
+		err = d.Set("master_count", int(masterCount))
+	} else if controlNodes, ok := cluster["control_nodes"].([]interface{}); ok && len(controlNodes) > 0 {
+		err = d.Set("master_count", len(controlNodes))
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if masterFlavorId, ok := cluster["master_flavor_id"].(string); ok && masterFlavorId != "" {
+		err = d.Set("master_flavor_id", masterFlavorId)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
 	err = d.Set("restriction_api", cluster["restriction_api"])
 	if err != nil {
 		return diag.FromErr(err)
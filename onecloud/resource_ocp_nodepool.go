@@ -2,8 +2,11 @@ package onecloud
 
 import (
 	"context"
+	"fmt"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/terraform-providers/terraform-provider-onecloud/internal/ocp_client"
 	"strings"
 	"time"
 )
@@ -14,9 +17,10 @@ func resourceNodePool() *schema.Resource {
 		ReadContext:   resourceOCPNodePoolRead,
 		UpdateContext: resourceOCPNodePoolUpdate,
 		DeleteContext: resourceOCPNodePoolDelete,
+		CustomizeDiff: resourceOCPNodePoolCustomizeDiff,
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(1 * time.Minute),
-			Update: schema.DefaultTimeout(1 * time.Minute),
+			Update: schema.DefaultTimeout(60 * time.Minute),
 			Delete: schema.DefaultTimeout(1 * time.Minute),
 		},
 		Schema: map[string]*schema.Schema{
@@ -25,7 +29,9 @@ func resourceNodePool() *schema.Resource {
 				Required: true,
 				ForceNew: true,
 				DiffSuppressFunc: func(_, old, new string, _ *schema.ResourceData) bool {
-					return strings.EqualFold(old, new)
+					// A flavor change swaps the pool for one named with the
+					// replacement suffix.
+					return strings.EqualFold(old, new) || strings.EqualFold(old, new+replacementNodePoolSuffix)
 				},
 			},
 			"cluster": {
@@ -36,12 +42,30 @@ func resourceNodePool() *schema.Resource {
 			"flavor_id": {
 				Type:     schema.TypeString,
 				Required: true,
-				ForceNew: true,
+				ForceNew: false,
 			},
 			"flavor": {
 				Type:     schema.TypeString,
 				Computed: true,
 			},
+			"drained_node_pool_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"image": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+			"kubernetes_version": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"current_kubernetes_version": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"upgrade_settings": upgradeSettingsSchema(),
 			"node_count": {
 				Type:     schema.TypeInt,
 				Required: true,
@@ -155,9 +179,21 @@ func resourceOCPNodePoolRead(ctx context.Context, d *schema.ResourceData, meta i
 		return diag.FromErr(err)
 	}
 
-	res, err := client.GetNodePool(ctx, d.Id())
-	if err != nil {
-		return diag.FromErr(err)
+	// Node pools of a cluster are read from the cached cluster payload, so a
+	// refresh makes one request per cluster.
+	var res map[string]interface{}
+	if clusterId, ok := d.Get("cluster").(string); ok && clusterId != "" {
+		cluster, err := getCachedCluster(ctx, meta, clusterId)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		res = clusterNodePool(cluster, d.Id())
+	}
+	if res == nil {
+		res, err = client.GetNodePool(ctx, d.Id())
+		if err != nil {
+			return diag.FromErr(err)
+		}
 	}
 
 	errFetch := fetchNodePoolState(res, d)
@@ -172,8 +208,18 @@ func resourceOCPNodePoolCreate(ctx context.Context, d *schema.ResourceData, meta
 	if err != nil {
 		return diag.FromErr(err)
 	}
+	config := meta.(*Config)
+	release, err := config.startMutation(ctx, d.Get("cluster").(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	defer release()
 	nodePoolData := GetNodePoolCreateOptions(d)
-	res, err := client.CreateNodePool(ctx, nodePoolData)
+	var res map[string]interface{}
+	err = retryOnConflict(ctx, config, *client, d.Timeout(schema.TimeoutCreate), func() (err error) {
+		res, err = client.CreateNodePool(ctx, nodePoolData)
+		return err
+	})
 	if err != nil {
 		return diag.FromErr(err)
 	}
@@ -187,13 +233,34 @@ func resourceOCPNodePoolUpdate(ctx context.Context, d *schema.ResourceData, meta
 	if err != nil {
 		return diag.FromErr(err)
 	}
-	if d.HasChange("node_count") || d.HasChange("autoscale") || d.HasChange("max_count") {
+	config := meta.(*Config)
+	release, err := config.startMutation(ctx, d.Get("cluster").(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	defer release()
+	if drainedId := d.Get("drained_node_pool_id").(string); drainedId != "" {
+		diags := deleteDrainedNodePool(ctx, d, config, client, drainedId, d.Timeout(schema.TimeoutDelete))
+		if diags.HasError() {
+			return diags
+		}
+	}
+	if d.HasChange("flavor_id") {
+		return replaceNodePool(ctx, d, config, client)
+	}
+	var diags diag.Diagnostics
+	if d.HasChanges("node_count", "autoscale", "max_count", "upgrade_settings") {
 		updateData := make(map[string]interface{})
 		updateData["count"] = d.Get("node_count")
 		updateData["autoscale"] = d.Get("autoscale")
 		updateData["max_count"] = d.Get("max_count")
+		updateData["upgrade_settings"] = getUpgradeSettings(d.Get("upgrade_settings").([]interface{}))
 
-		res, err := client.UpdateNodePool(ctx, d.Id(), updateData)
+		var res map[string]interface{}
+		err := retryOnConflict(ctx, config, *client, d.Timeout(schema.TimeoutUpdate), func() (err error) {
+			res, err = client.UpdateNodePool(ctx, d.Id(), updateData)
+			return err
+		})
 		if err != nil {
 			return diag.FromErr(err)
 		}
@@ -210,8 +277,50 @@ func resourceOCPNodePoolUpdate(ctx context.Context, d *schema.ResourceData, meta
 		if err != nil {
 			return diag.FromErr(err)
 		}
+		if settings, ok := res["upgrade_settings"].(map[string]interface{}); ok {
+			err = d.Set("upgrade_settings", flattenUpgradeSettings(settings))
+			if err != nil {
+				return diag.FromErr(err)
+			}
+		}
 	}
-	return nil
+	if d.HasChange("image") || d.HasChange("kubernetes_version") {
+		updateData := map[string]interface{}{
+			"upgrade_settings": getUpgradeSettings(d.Get("upgrade_settings").([]interface{})),
+		}
+		if d.HasChange("image") {
+			updateData["image"] = d.Get("image")
+		}
+		if d.HasChange("kubernetes_version") {
+			updateData["kubernetes_version"] = nodePoolVersionPin(d.Get("kubernetes_version").(string))
+		}
+
+		var res map[string]interface{}
+		err := retryOnConflict(ctx, config, *client, d.Timeout(schema.TimeoutUpdate), func() (err error) {
+			res, err = client.UpdateNodePool(ctx, d.Id(), updateData)
+			return err
+		})
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		if operationId, ok := res["operation_id"].(string); ok {
+			_, progress, waitErr := waitForOperationSuccess(ctx, config, *client, operationId, d.Timeout(schema.TimeoutUpdate))
+			if waitErr != nil {
+				return append(progress, operationDiagnostics(waitErr)...)
+			}
+			diags = append(diags, progress...)
+		}
+
+		nodePool, err := client.GetNodePool(ctx, d.Id())
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		errFetch := fetchNodePoolState(nodePool, d)
+		if errFetch != nil {
+			return errFetch
+		}
+	}
+	return diags
 }
 
 func resourceOCPNodePoolDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -219,47 +328,280 @@ func resourceOCPNodePoolDelete(ctx context.Context, d *schema.ResourceData, meta
 	if err != nil {
 		return diag.FromErr(err)
 	}
-	err = client.DeleteNodePool(ctx, d.Id())
+	config := meta.(*Config)
+	release, err := config.startMutation(ctx, d.Get("cluster").(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	defer release()
+	if drainedId := d.Get("drained_node_pool_id").(string); drainedId != "" {
+		diags := deleteDrainedNodePool(ctx, d, config, client, drainedId, d.Timeout(schema.TimeoutDelete))
+		if diags.HasError() {
+			return diags
+		}
+	}
+	err = retryOnConflict(ctx, config, *client, d.Timeout(schema.TimeoutDelete), func() error {
+		return client.DeleteNodePool(ctx, d.Id())
+	})
 	if err != nil {
 		return diag.FromErr(err)
 	}
 	return nil
 }
 
-func fetchNodePoolState(nodePool map[string]interface{}, d *schema.ResourceData) diag.Diagnostics {
-	err := d.Set("name", nodePool["name"])
+func resourceOCPNodePoolCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() != "" && d.Get("drained_node_pool_id").(string) != "" {
+		// The old pool of a swap is left over, the apply deletes it in Update.
+		if err := d.SetNewComputed("drained_node_pool_id"); err != nil {
+			return err
+		}
+	}
+	if err := validateUpgradeSettings(d, "upgrade_settings.0"); err != nil {
+		return err
+	}
+
+	if !d.NewValueKnown("cluster") || !d.NewValueKnown("kubernetes_version") {
+		return nil
+	}
+	poolVersion := d.Get("kubernetes_version").(string)
+	if poolVersion == "" || !d.HasChange("kubernetes_version") {
+		return nil
+	}
+
+	cluster, err := getCachedCluster(ctx, meta, d.Get("cluster").(string))
 	if err != nil {
-		return diag.FromErr(err)
+		return err
 	}
-	err = d.Set("flavor", nodePool["flavor"])
+	clusterVersion, ok := cluster["cluster_version"].(string)
+	if !ok || clusterVersion == "" {
+		return nil
+	}
+	return validateVersionSkew(clusterVersion, poolVersion)
+}
+
+// replaceNodePool swaps the node pool for one with the new flavor without
+// losing capacity: the replacement pool is created with the same labels and
+// taints and at least as many nodes as the old pool has ready, and the old
+// pool is drained only once that many new nodes are ready. Pool names
+// alternate between the configured name and the name with
+// replacementNodePoolSuffix, so the replacement never collides with the old
+// pool. The whole swap runs within the update timeout.
+//
+// Until the old pool is drained the replacement is deleted on failure and the
+// state keeps the old pool, so the next apply tries again. Once the workload
+// has moved, the state switches to the replacement and keeps the old pool in
+// drained_node_pool_id until it is deleted, a failed delete is retried by the
+// next apply.
+func replaceNodePool(ctx context.Context, d *schema.ResourceData, config *Config, client *ocp_client.Client) diag.Diagnostics {
+	oldId := d.Id()
+	deadline := time.Now().Add(d.Timeout(schema.TimeoutUpdate))
+	cleanupTimeout := d.Timeout(schema.TimeoutDelete)
+
+	oldPool, err := client.GetNodePool(ctx, oldId)
 	if err != nil {
 		return diag.FromErr(err)
 	}
-	err = d.Set("node_count", nodePool["count"])
+	oldReady := readyNodes(oldPool)
+
+	nodePoolData := GetNodePoolCreateOptions(d)
+	nodePoolData.Name = replacementNodePoolName(d.Get("name").(string))
+	count := nodePoolData.Count
+	if nodePoolData.Count < oldReady {
+		// Scaled down to node_count once the old pool is gone.
+		nodePoolData.Count = oldReady
+	}
+	var res map[string]interface{}
+	err = retryOnConflict(ctx, config, *client, time.Until(deadline), func() (err error) {
+		res, err = client.CreateNodePool(ctx, nodePoolData)
+		return err
+	})
 	if err != nil {
 		return diag.FromErr(err)
 	}
-	err = d.Set("autoscale", nodePool["autoscale"])
+	newId := res["id"].(string)
+
+	readyErr := waitForNodePoolReady(ctx, config, client, newId, nodePoolData.Count, deadline)
+	if readyErr != nil {
+		return deleteReplacementNodePool(ctx, config, client, newId, cleanupTimeout,
+			diag.Errorf("replacement node pool %s doesn't have %d ready nodes: %s", newId, nodePoolData.Count, readyErr))
+	}
+
+	var diags diag.Diagnostics
+	var drainRes map[string]interface{}
+	err = retryOnConflict(ctx, config, *client, time.Until(deadline), func() (err error) {
+		drainRes, err = client.DrainNodePool(ctx, oldId, map[string]interface{}{
+			"upgrade_settings": nodePoolData.UpgradeSettings,
+		})
+		return err
+	})
 	if err != nil {
-		return diag.FromErr(err)
+		return deleteReplacementNodePool(ctx, config, client, newId, cleanupTimeout,
+			diag.Errorf("node pool %s can't be drained: %s", oldId, err))
+	}
+	if operationId, ok := drainRes["operation_id"].(string); ok {
+		_, progress, waitErr := waitForOperationSuccess(ctx, config, *client, operationId, time.Until(deadline))
+		diags = append(diags, progress...)
+		if waitErr != nil {
+			return deleteReplacementNodePool(ctx, config, client, newId, cleanupTimeout,
+				append(diags, operationDiagnostics(waitErr)...))
+		}
 	}
-	err = d.Set("max_count", nodePool["max_count"])
+
+	// The workload runs on the replacement now, it is the pool to track. The
+	// old pool is tracked until it is deleted.
+	d.SetId(newId)
+	err = d.Set("name", nodePoolData.Name)
+	if err != nil {
+		return append(diags, diag.FromErr(err)...)
+	}
+	err = d.Set("drained_node_pool_id", oldId)
+	if err != nil {
+		return append(diags, diag.FromErr(err)...)
+	}
+	diags = append(diags, deleteDrainedNodePool(ctx, d, config, client, oldId, time.Until(deadline))...)
+	if diags.HasError() {
+		return diags
+	}
+
+	if nodePoolData.Count != count {
+		err = retryOnConflict(ctx, config, *client, time.Until(deadline), func() error {
+			_, err := client.UpdateNodePool(ctx, newId, map[string]interface{}{
+				"count":            count,
+				"autoscale":        nodePoolData.Autoscale,
+				"max_count":        nodePoolData.MaxCount,
+				"upgrade_settings": nodePoolData.UpgradeSettings,
+			})
+			return err
+		})
+		if err != nil {
+			return append(diags, diag.Errorf("replacement node pool %s can't be scaled to %d nodes: %s", newId, count, err)...)
+		}
+	}
+
+	nodePool, err := client.GetNodePool(ctx, newId)
+	if err != nil {
+		return append(diags, diag.FromErr(err)...)
+	}
+	return append(diags, fetchNodePoolState(nodePool, d)...)
+}
+
+// replacementNodePoolName returns the name of the pool that replaces a pool
+// named name.
+func replacementNodePoolName(name string) string {
+	if strings.HasSuffix(name, replacementNodePoolSuffix) {
+		return strings.TrimSuffix(name, replacementNodePoolSuffix)
+	}
+	return name + replacementNodePoolSuffix
+}
+
+// deleteReplacementNodePool deletes a replacement pool after the swap failed,
+// the state still holds the old pool.
+func deleteReplacementNodePool(ctx context.Context, config *Config, client *ocp_client.Client, nodePoolId string, timeout time.Duration, diags diag.Diagnostics) diag.Diagnostics {
+	if ctx.Err() != nil {
+		ctx = context.WithoutCancel(ctx)
+	}
+	err := retryOnConflict(ctx, config, *client, timeout, func() error {
+		return client.DeleteNodePool(ctx, nodePoolId)
+	})
+	if err != nil {
+		return append(diags, diag.Errorf("replacement node pool %s can't be deleted, delete it manually: %s", nodePoolId, err)...)
+	}
+	return diags
+}
+
+// deleteDrainedNodePool deletes the old pool of a swap recorded in
+// drained_node_pool_id and clears it. If the delete fails the ID is kept and
+// the next apply tries again.
+func deleteDrainedNodePool(ctx context.Context, d *schema.ResourceData, config *Config, client *ocp_client.Client, nodePoolId string, timeout time.Duration) diag.Diagnostics {
+	err := retryOnConflict(ctx, config, *client, timeout, func() error {
+		return client.DeleteNodePool(ctx, nodePoolId)
+	})
+	if err != nil {
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "Drained node pool can't be deleted",
+			Detail: fmt.Sprintf("The workload was moved to node pool %s. The old node pool %s is drained but can't be deleted: %s. "+
+				"It is recorded in drained_node_pool_id and the next apply deletes it.", d.Id(), nodePoolId, err),
+		}}
+	}
+	return diag.FromErr(d.Set("drained_node_pool_id", ""))
+}
+
+// waitForNodePoolReady polls the node pool with the operation poll settings
+// until count of its nodes are ready.
+func waitForNodePoolReady(ctx context.Context, config *Config, client *ocp_client.Client, nodePoolId string, count int, deadline time.Time) error {
+	return pollUntil(ctx, config, deadline, nodePoolStatePending, nodePoolStateReady, func() (bool, error) {
+		nodePool, err := client.GetNodePool(ctx, nodePoolId)
+		if err != nil {
+			return false, err
+		}
+		return readyNodes(nodePool) >= count, nil
+	})
+}
+
+// readyNodes returns the number of ready nodes of a node pool.
+func readyNodes(nodePool map[string]interface{}) int {
+	nodes, _ := nodePool["nodes"].([]interface{})
+	ready := 0
+	for _, n := range nodes {
+		if node, ok := n.(map[string]interface{}); ok && node["ready"] == true {
+			ready++
+		}
+	}
+	return ready
+}
+
+// nodePoolVersionPin returns the kubernetes_version sent to the API, a pool
+// without a pin follows the cluster version.
+func nodePoolVersionPin(version string) interface{} {
+	if version == "" {
+		return nil
+	}
+	return version
+}
+
+// nodePoolVersion returns the Kubernetes version of a node pool. Pools
+// without a pinned version report the oldest version of their nodes.
+func nodePoolVersion(nodePool map[string]interface{}) string {
+	if v, ok := nodePool["kubernetes_version"].(string); ok && v != "" {
+		return v
+	}
+
+	var poolVersion string
+	nodes, _ := nodePool["nodes"].([]interface{})
+	for _, n := range nodes {
+		node, _ := n.(map[string]interface{})
+		nodeVersion, _ := node["version"].(string)
+		if nodeVersion == "" {
+			continue
+		}
+		if poolVersion == "" || compareVersions(nodeVersion, poolVersion) < 0 {
+			poolVersion = nodeVersion
+		}
+	}
+	return poolVersion
+}
+
+// fetchNodePoolState sets the state from a node pool payload. Fields left
+// out of the payload, as embedded node pools may do, keep their state.
+func fetchNodePoolState(nodePool map[string]interface{}, d *schema.ResourceData) diag.Diagnostics {
+	err := d.Set("name", nodePool["name"])
 	if err != nil {
 		return diag.FromErr(err)
 	}
-	err = d.Set("is_default", nodePool["is_default"])
+	err = d.Set("flavor", nodePool["flavor"])
 	if err != nil {
 		return diag.FromErr(err)
 	}
-	err = d.Set("status", nodePool["status"])
+	err = d.Set("current_kubernetes_version", nodePoolVersion(nodePool))
 	if err != nil {
 		return diag.FromErr(err)
 	}
-	err = d.Set("labels", nodePool["labels"])
+	err = d.Set("node_count", nodePool["count"])
 	if err != nil {
 		return diag.FromErr(err)
 	}
-	err = d.Set("taints", nodePool["taints"])
+	err = d.Set("status", nodePool["status"])
 	if err != nil {
 		return diag.FromErr(err)
 	}
@@ -267,5 +609,81 @@ func fetchNodePoolState(nodePool map[string]interface{}, d *schema.ResourceData)
 	if err != nil {
 		return diag.FromErr(err)
 	}
+	for _, field := range []string{"image", "kubernetes_version", "autoscale", "max_count", "is_default", "labels", "taints"} {
+		value, ok := nodePool[field]
+		if !ok {
+			continue
+		}
+		err = d.Set(field, value)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	if settings, ok := nodePool["upgrade_settings"].(map[string]interface{}); ok {
+		err = d.Set("upgrade_settings", flattenUpgradeSettings(settings))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	return nil
+}
+
+const defaultDrainTimeout = 600
+
+const replacementNodePoolSuffix = "-replacement"
+
+const nodePoolStatePending = "pending"
+const nodePoolStateReady = "ready"
+
+// validateUpgradeSettings rejects upgrade settings that can't make progress:
+// without surge nodes and without unavailable nodes no node can be replaced.
+func validateUpgradeSettings(d *schema.ResourceDiff, prefix string) error {
+	maxSurgeKey, maxUnavailableKey := prefix+".max_surge", prefix+".max_unavailable"
+	if !d.NewValueKnown(maxSurgeKey) || !d.NewValueKnown(maxUnavailableKey) {
+		return nil
+	}
+	if _, ok := d.GetOk(prefix); !ok {
+		return nil
+	}
+	if d.Get(maxSurgeKey).(int) == 0 && d.Get(maxUnavailableKey).(int) == 0 {
+		return fmt.Errorf("%s and %s can't both be 0, at least one node must be added or taken down at a time",
+			maxSurgeKey, maxUnavailableKey)
+	}
 	return nil
 }
+
+func upgradeSettingsSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeList,
+		Optional: true,
+		Computed: true,
+		MaxItems: 1,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"max_surge": {
+					Type:         schema.TypeInt,
+					Optional:     true,
+					Default:      1,
+					ValidateFunc: validation.IntAtLeast(0),
+				},
+				"max_unavailable": {
+					Type:         schema.TypeInt,
+					Optional:     true,
+					Default:      0,
+					ValidateFunc: validation.IntAtLeast(0),
+				},
+				"drain_timeout": {
+					Type:         schema.TypeInt,
+					Optional:     true,
+					Default:      defaultDrainTimeout,
+					ValidateFunc: validation.IntAtLeast(0),
+				},
+				"force_drain_after_timeout": {
+					Type:     schema.TypeBool,
+					Optional: true,
+					Default:  false,
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,105 @@
+package onecloud
+
+import (
+	"fmt"
+	"github.com/hashicorp/go-version"
+	"strings"
+)
+
+// maxNodeMinorVersionSkew is the number of minor versions the kubelet may lag
+// behind the control plane.
+const maxNodeMinorVersionSkew = 3
+
+// versionMatches reports whether v satisfies the constraints. Versions that
+// cannot be parsed as semver never match.
+func versionMatches(v string, constraints version.Constraints) bool {
+	parsed, err := version.NewVersion(v)
+	if err != nil {
+		return false
+	}
+	return constraints.Check(parsed)
+}
+
+// validateVersionConstraint is a schema.SchemaValidateFunc for version
+// constraints such as "~> 1.29" or ">= 1.28, < 1.30".
+func validateVersionConstraint(v interface{}, k string) ([]string, []error) {
+	if _, err := version.NewConstraint(v.(string)); err != nil {
+		return nil, []error{fmt.Errorf("%q is not a valid version constraint, %w", k, err)}
+	}
+	return nil, nil
+}
+
+// versionsEqual reports whether both versions parse and are semantically
+// equal.
+func versionsEqual(a, b string) bool {
+	va, errA := version.NewVersion(a)
+	vb, errB := version.NewVersion(b)
+	return errA == nil && errB == nil && va.Equal(vb)
+}
+
+// supportsClusterVersion reports whether a cluster version is in the list of
+// supported versions. Entries may be full versions or a major.minor version
+// covering all its patch releases. An empty list is treated as unknown and
+// supports every version.
+func supportsClusterVersion(supported []string, clusterVersion string) bool {
+	if len(supported) == 0 {
+		return true
+	}
+	cv, err := version.NewVersion(clusterVersion)
+	if err != nil {
+		return false
+	}
+	for _, s := range supported {
+		sv, err := version.NewVersion(s)
+		if err != nil {
+			continue
+		}
+		if sv.Equal(cv) {
+			return true
+		}
+		ss, cs := sv.Segments(), cv.Segments()
+		if len(strings.Split(strings.TrimPrefix(s, "v"), ".")) == 2 && ss[0] == cs[0] && ss[1] == cs[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// compareVersions orders two version strings semantically. Unparsable
+// versions sort before any valid one and fall back to string order.
+func compareVersions(a, b string) int {
+	va, errA := version.NewVersion(a)
+	vb, errB := version.NewVersion(b)
+	switch {
+	case errA != nil && errB != nil:
+		return strings.Compare(a, b)
+	case errA != nil:
+		return -1
+	case errB != nil:
+		return 1
+	}
+	return va.Compare(vb)
+}
+
+// validateVersionSkew checks that a node pool version is supported by the
+// control plane version: same major, not newer and at most
+// maxNodeMinorVersionSkew minor versions older.
+func validateVersionSkew(clusterVersion, poolVersion string) error {
+	cv, err := version.NewVersion(clusterVersion)
+	if err != nil {
+		return fmt.Errorf("can't parse cluster version %q, %w", clusterVersion, err)
+	}
+	pv, err := version.NewVersion(poolVersion)
+	if err != nil {
+		return fmt.Errorf("can't parse node pool version %q, %w", poolVersion, err)
+	}
+
+	cs, ps := cv.Segments(), pv.Segments()
+	if cs[0] != ps[0] || pv.GreaterThan(cv) || cs[1]-ps[1] > maxNodeMinorVersionSkew {
+		return fmt.Errorf(
+			"node pool version %s is not supported by cluster version %s, "+
+				"node pools can't be newer than the control plane or more than %d minor versions older",
+			poolVersion, clusterVersion, maxNodeMinorVersionSkew)
+	}
+	return nil
+}
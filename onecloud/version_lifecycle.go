@@ -0,0 +1,96 @@
+package onecloud
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/terraform-providers/terraform-provider-onecloud/internal/ocp_client"
+	"math"
+	"time"
+)
+
+// endOfSupportWarningPeriod is how long before the end of support of its
+// version a cluster gets a warning.
+const endOfSupportWarningPeriod = 90 * 24 * time.Hour
+
+// findClusterVersion returns the catalog entry of a version, comparing
+// semantically so "v1.29.1" matches "1.29.1".
+func findClusterVersion(versions []ocp_client.ClusterVersion, v string) *ocp_client.ClusterVersion {
+	for i := range versions {
+		if versions[i].Version == v {
+			return &versions[i]
+		}
+	}
+	for i := range versions {
+		if versionsEqual(versions[i].Version, v) {
+			return &versions[i]
+		}
+	}
+	return nil
+}
+
+// parseSupportDate parses the dates of the version catalog, which are either
+// dates or RFC 3339 timestamps.
+func parseSupportDate(s string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// setClusterVersionLifecycle sets upgrade_available from the version catalog
+// and warns if the cluster version is near or past its end of support. If the
+// catalog can't be read, the attributes are left as they are.
+func setClusterVersionLifecycle(ctx context.Context, d *schema.ResourceData, client *ocp_client.Client) diag.Diagnostics {
+	clusterVersion := d.Get("cluster_version").(string)
+	versions, err := client.ClusterVersions(ctx)
+	if err != nil {
+		tflog.Warn(ctx, "Can't read the cluster version catalog", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return nil
+	}
+	catalogVersion := findClusterVersion(versions, clusterVersion)
+	if catalogVersion == nil {
+		return nil
+	}
+
+	err = d.Set("upgrade_available", len(catalogVersion.AvailableUpgrades) > 0)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if catalogVersion.EndOfSupport == "" {
+		return nil
+	}
+	endOfSupport, err := parseSupportDate(catalogVersion.EndOfSupport)
+	if err != nil {
+		tflog.Warn(ctx, "Can't parse end of support", map[string]interface{}{
+			"version":        clusterVersion,
+			"end_of_support": catalogVersion.EndOfSupport,
+		})
+		return nil
+	}
+	remaining := time.Until(endOfSupport)
+	if remaining > endOfSupportWarningPeriod {
+		return nil
+	}
+
+	summary := fmt.Sprintf("Kubernetes %s reached its end of support", clusterVersion)
+	if remaining > 0 {
+		summary = fmt.Sprintf("Kubernetes %s reaches its end of support in %d days",
+			clusterVersion, int(math.Ceil(remaining.Hours()/24)))
+	}
+	detail := fmt.Sprintf("Support for the version of cluster %s ends on %s.",
+		d.Get("cluster_name"), endOfSupport.Format(time.DateOnly))
+	if len(catalogVersion.AvailableUpgrades) > 0 {
+		detail = fmt.Sprintf("%s It can be upgraded to %v.", detail, catalogVersion.AvailableUpgrades)
+	}
+	return diag.Diagnostics{{
+		Severity: diag.Warning,
+		Summary:  summary,
+		Detail:   detail,
+	}}
+}